@@ -0,0 +1,330 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plog
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pfmt/pfmt"
+)
+
+// Field names presets of the access log.
+const (
+	AccessPlain    = iota // AccessPlain is a plain keys, for example "method" and "status".
+	AccessECS             // AccessECS is an Elastic Common Schema keys, for example "http.request.method".
+	AccessGCP             // AccessGCP is a Google Cloud Logging "httpRequest" object.
+	AccessCombined        // AccessCombined is a plain keys and an Apache combined log format message.
+)
+
+// Access is a HTTP middleware which writes one record per request
+// with the method, path, route, status, response bytes, duration,
+// remote address, user agent and request ID.
+type Access struct {
+	Log        Logger                          // Log is a logger of the records, logger of the request context if nil.
+	Format     int                             // Format is a field names preset: plain, ECS, GCP or Apache combined.
+	Route      func(r *http.Request) string    // Route returns route pattern of the served request, no route if nil.
+	RequestID  string                          // RequestID is a request ID header, "X-Request-Id" if empty.
+	Level      string                          // Level is a severity level of the records, "info" if empty.
+	Slow       time.Duration                   // Slow is a duration of the slow requests, off if zero.
+	SlowLevel  string                          // SlowLevel is a severity level of the slow requests, "warning" if empty.
+	ErrorLevel string                          // ErrorLevel is a severity level of the 5xx responses, "error" if empty.
+	Now        func() time.Time                // Now returns current time, time.Now if nil.
+	Since      func(t time.Time) time.Duration // Since returns duration of the request, time.Since if nil.
+}
+
+// Handler returns handler which serves the request by the next handler
+// and writes the access record.
+func (a *Access) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		now, since := time.Now, time.Since
+		if a.Now != nil {
+			now = a.Now
+		}
+		if a.Since != nil {
+			since = a.Since
+		}
+
+		start := now()
+		rw := &accessWriter{ResponseWriter: w}
+		w = rw.wrap()
+
+		// Writes the record of the panicked handler too,
+		// then panics again, so the server aborts the response.
+		defer func() {
+			v := recover()
+			if v != nil {
+				rw.status = http.StatusInternalServerError
+			}
+			_ = a.write(r.Context(), r, rw, start, since(start))
+			if v != nil {
+				panic(v)
+			}
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// write writes the access record of the request.
+func (a *Access) write(ctx context.Context, r *http.Request, rw *accessWriter, start time.Time, d time.Duration) error {
+	l := a.Log
+	if l == nil {
+		l = FromContext(ctx)
+	}
+
+	status := rw.status
+	if status == 0 {
+		status = http.StatusOK
+		if rw.hijacked {
+			status = http.StatusSwitchingProtocols
+		}
+	}
+
+	level := a.Level
+	if level == "" {
+		level = "info"
+	}
+	if a.Slow > 0 && d >= a.Slow {
+		level = a.SlowLevel
+		if level == "" {
+			level = "warning"
+		}
+	}
+	if status >= 500 {
+		level = a.ErrorLevel
+		if level == "" {
+			level = "error"
+		}
+	}
+
+	header := a.RequestID
+	if header == "" {
+		header = "X-Request-Id"
+	}
+
+	var route string
+	if a.Route != nil {
+		route = a.Route(r)
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	e := accessEntry{
+		method:    r.Method,
+		path:      r.URL.Path,
+		route:     route,
+		status:    status,
+		bytes:     rw.bytes,
+		duration:  d,
+		remote:    r.RemoteAddr,
+		host:      host,
+		userAgent: r.UserAgent(),
+		requestID: r.Header.Get(header),
+	}
+
+	// Severity level goes first, so the Level function of the logger selects the output.
+	kv := append([]pfmt.KV{levelKV(l, level)}, a.fields(e)...)
+
+	if c, ok := l.(interface{ Context(context.Context) Logger }); ok {
+		l = c.Context(ctx)
+		defer l.Close()
+	}
+
+	l = l.Tee(kv...)
+	defer l.Close()
+
+	msg := r.Method + " " + r.URL.RequestURI() + " " + strconv.Itoa(status)
+	if a.Format == AccessCombined {
+		msg = combined(r, host, status, rw.bytes, start)
+	}
+
+	_, err = l.Write([]byte(msg))
+	return err
+}
+
+// accessEntry is a request of the access record.
+type accessEntry struct {
+	method, path, route  string
+	status               int
+	bytes                int64
+	duration             time.Duration
+	remote, host         string
+	userAgent, requestID string
+}
+
+// fields returns key-values of the request for the field names preset,
+// empty route and request ID are omitted.
+func (a *Access) fields(e accessEntry) []pfmt.KV {
+	var kv []pfmt.KV
+
+	switch a.Format {
+	case AccessECS:
+		kv = []pfmt.KV{
+			StringString("http.request.method", e.method),
+			StringString("url.path", e.path),
+			StringInt("http.response.status_code", e.status),
+			StringInt64("http.response.body.bytes", e.bytes),
+			StringInt64("event.duration", e.duration.Nanoseconds()),
+			StringString("source.address", e.host),
+			StringString("user_agent.original", e.userAgent),
+		}
+		if e.route != "" {
+			kv = append(kv, StringString("http.route", e.route))
+		}
+		if e.requestID != "" {
+			kv = append(kv, StringString("http.request.id", e.requestID))
+		}
+
+	case AccessGCP:
+		// <https://cloud.google.com/logging/docs/reference/v2/rest/v2/LogEntry#HttpRequest>.
+		kv = []pfmt.KV{
+			StringGroup("httpRequest",
+				StringString("requestMethod", e.method),
+				StringString("requestUrl", e.path),
+				StringInt("status", e.status),
+				StringString("responseSize", strconv.FormatInt(e.bytes, 10)),
+				StringString("latency", strconv.FormatFloat(e.duration.Seconds(), 'f', -1, 64)+"s"),
+				StringString("remoteIp", e.host),
+				StringString("userAgent", e.userAgent),
+			),
+		}
+		if e.route != "" {
+			kv = append(kv, StringString("route", e.route))
+		}
+		if e.requestID != "" {
+			kv = append(kv, StringString("request_id", e.requestID))
+		}
+
+	default:
+		kv = []pfmt.KV{
+			StringString("method", e.method),
+			StringString("path", e.path),
+			StringInt("status", e.status),
+			StringInt64("bytes", e.bytes),
+			StringDuration("duration", e.duration),
+			StringString("remote_addr", e.remote),
+			StringString("user_agent", e.userAgent),
+		}
+		if e.route != "" {
+			kv = append(kv, StringString("route", e.route))
+		}
+		if e.requestID != "" {
+			kv = append(kv, StringString("request_id", e.requestID))
+		}
+	}
+
+	return kv
+}
+
+// combined returns Apache combined log format line of the request.
+func combined(r *http.Request, host string, status int, bytes int64, start time.Time) string {
+	size := "-"
+	if bytes > 0 {
+		size = strconv.FormatInt(bytes, 10)
+	}
+
+	return host + " - " + user(r) + " [" + start.Format("02/Jan/2006:15:04:05 -0700") + "] " +
+		strconv.Quote(r.Method+" "+r.URL.RequestURI()+" "+r.Proto) + " " +
+		strconv.Itoa(status) + " " + size + " " +
+		strconv.Quote(dash(r.Referer())) + " " + strconv.Quote(dash(r.UserAgent()))
+}
+
+// user returns basic authentication user of the request or dash.
+func user(r *http.Request) string {
+	if u, _, ok := r.BasicAuth(); ok && u != "" {
+		return u
+	}
+	return "-"
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// accessWriter is a response writer which records status and size of the response.
+// It unwraps for the http.ResponseController.
+type accessWriter struct {
+	http.ResponseWriter
+	status   int
+	bytes    int64
+	hijacked bool
+}
+
+// wrap returns response writer which implements http.Flusher and http.Hijacker
+// only if the wrapped writer implements them, for example HTTP/2 is not a hijacker.
+func (w *accessWriter) wrap() http.ResponseWriter {
+	_, flusher := w.ResponseWriter.(http.Flusher)
+	_, hijacker := w.ResponseWriter.(http.Hijacker)
+
+	switch {
+	case flusher && hijacker:
+		return accessFlushHijacker{w}
+	case flusher:
+		return accessFlusher{w}
+	case hijacker:
+		return accessHijacker{w}
+	}
+	return w
+}
+
+func (w *accessWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *accessWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap returns the wrapped writer for the http.ResponseController.
+func (w *accessWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *accessWriter) flush() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+func (w *accessWriter) hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := w.ResponseWriter.(http.Hijacker).Hijack()
+	if err == nil {
+		w.hijacked = true
+	}
+	return conn, rw, err
+}
+
+type accessFlusher struct{ *accessWriter }
+
+func (w accessFlusher) Flush() { w.flush() }
+
+type accessHijacker struct{ *accessWriter }
+
+func (w accessHijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) { return w.hijack() }
+
+type accessFlushHijacker struct{ *accessWriter }
+
+func (w accessFlushHijacker) Flush()                                       { w.flush() }
+func (w accessFlushHijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) { return w.hijack() }
@@ -0,0 +1,320 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plog_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kinbiko/jsonassert"
+	"github.com/pfmt/pfmt"
+	"github.com/pfmt/plog"
+)
+
+var accessTests = []struct {
+	name    string
+	line    string
+	access  plog.Access
+	status  int
+	elapsed time.Duration
+	want    string
+	wantErr string
+}{
+	{
+		name:    "plain",
+		line:    line(),
+		access:  plog.Access{Route: func(*http.Request) string { return "/users/{id}" }},
+		status:  http.StatusOK,
+		elapsed: 1500 * time.Millisecond,
+		want: `{
+			"message":"GET /users/42?q=1 200",
+			"level":"info",
+			"method":"GET",
+			"path":"/users/42",
+			"route":"/users/{id}",
+			"status":200,
+			"bytes":13,
+			"duration":"1.5s",
+			"remote_addr":"192.0.2.1:1234",
+			"user_agent":"curl/8.0",
+			"request_id":"req-1"
+		}`,
+	},
+	{
+		name:    "ECS",
+		line:    line(),
+		access:  plog.Access{Format: plog.AccessECS},
+		status:  http.StatusCreated,
+		elapsed: time.Second,
+		want: `{
+			"message":"GET /users/42?q=1 201",
+			"level":"info",
+			"http.request.method":"GET",
+			"url.path":"/users/42",
+			"http.response.status_code":201,
+			"http.response.body.bytes":13,
+			"event.duration":1000000000,
+			"source.address":"192.0.2.1",
+			"user_agent.original":"curl/8.0",
+			"http.request.id":"req-1"
+		}`,
+	},
+	{
+		name:    "GCP",
+		line:    line(),
+		access:  plog.Access{Format: plog.AccessGCP},
+		status:  http.StatusOK,
+		elapsed: 250 * time.Millisecond,
+		want: `{
+			"message":"GET /users/42?q=1 200",
+			"level":"info",
+			"httpRequest":{
+				"requestMethod":"GET",
+				"requestUrl":"/users/42",
+				"status":200,
+				"responseSize":"13",
+				"latency":"0.25s",
+				"remoteIp":"192.0.2.1",
+				"userAgent":"curl/8.0"
+			},
+			"request_id":"req-1"
+		}`,
+	},
+	{
+		name:    "Apache combined",
+		line:    line(),
+		access:  plog.Access{Format: plog.AccessCombined},
+		status:  http.StatusOK,
+		elapsed: time.Second,
+		want: `{
+			"message":"192.0.2.1 - - [02/Jan/2006:15:04:05 +0000] \"GET /users/42?q=1 HTTP/1.1\" 200 13 \"-\" \"curl/8.0\"",
+			"level":"info",
+			"method":"GET",
+			"path":"/users/42",
+			"status":200,
+			"bytes":13,
+			"duration":"1s",
+			"remote_addr":"192.0.2.1:1234",
+			"user_agent":"curl/8.0",
+			"request_id":"req-1"
+		}`,
+	},
+	{
+		name:    "slow request",
+		line:    line(),
+		access:  plog.Access{Slow: time.Second},
+		status:  http.StatusOK,
+		elapsed: 2 * time.Second,
+		want: `{
+			"message":"GET /users/42?q=1 200",
+			"level":"warning",
+			"method":"GET",
+			"path":"/users/42",
+			"status":200,
+			"bytes":13,
+			"duration":"2s",
+			"remote_addr":"192.0.2.1:1234",
+			"user_agent":"curl/8.0",
+			"request_id":"req-1"
+		}`,
+	},
+	{
+		name:    "server error to the error output",
+		line:    line(),
+		access:  plog.Access{Slow: time.Second, ErrorLevel: "crit"},
+		status:  http.StatusBadGateway,
+		elapsed: 2 * time.Second,
+		wantErr: `{
+			"message":"GET /users/42?q=1 502",
+			"level":"crit",
+			"method":"GET",
+			"path":"/users/42",
+			"status":502,
+			"bytes":13,
+			"duration":"2s",
+			"remote_addr":"192.0.2.1:1234",
+			"user_agent":"curl/8.0",
+			"request_id":"req-1"
+		}`,
+	},
+}
+
+func TestAccess(t *testing.T) {
+	for _, tt := range accessTests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf, errBuf bytes.Buffer
+
+			l := &plog.Log{
+				Output: &buf,
+				Keys:   [4]encoding.TextMarshaler{pfmt.String("message")},
+				Level: func(level string) io.Writer {
+					if level == "crit" {
+						return &errBuf
+					}
+					return nil
+				},
+			}
+
+			a := tt.access
+			a.Log = l
+			a.Now = func() time.Time { return time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC) }
+			a.Since = func(time.Time) time.Duration { return tt.elapsed }
+
+			h := a.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte("Hello, World!"))
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/users/42?q=1", nil)
+			r.RemoteAddr = "192.0.2.1:1234"
+			r.Header.Set("User-Agent", "curl/8.0")
+			r.Header.Set("X-Request-Id", "req-1")
+
+			h.ServeHTTP(httptest.NewRecorder(), r)
+
+			ja := jsonassert.New(testprinter{t: t, link: tt.line})
+			if tt.want != "" {
+				ja.Assertf(buf.String(), tt.want)
+			} else if buf.Len() != 0 {
+				t.Errorf("unwant record of the output: %s %s", buf.String(), tt.line)
+			}
+			if tt.wantErr != "" {
+				ja.Assertf(errBuf.String(), tt.wantErr)
+			} else if errBuf.Len() != 0 {
+				t.Errorf("unwant record of the error output: %s %s", errBuf.String(), tt.line)
+			}
+		})
+	}
+}
+
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+}
+
+func (hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	c, _ := net.Pipe()
+	return c, nil, nil
+}
+
+func TestAccessFlushHijack(t *testing.T) {
+	var buf bytes.Buffer
+
+	a := plog.Access{
+		Log: &plog.Log{
+			Output: &buf,
+			Keys:   [4]encoding.TextMarshaler{pfmt.String("message")},
+			KV:     []pfmt.KV{plog.StringString("service", "api")},
+		},
+		Since: func(time.Time) time.Duration { return time.Millisecond },
+	}
+
+	rec := httptest.NewRecorder()
+
+	h := a.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f, ok := w.(http.Flusher)
+		if !ok {
+			t.Fatal("want flusher")
+		}
+		f.Flush()
+
+		if _, ok := w.(http.Hijacker); ok {
+			t.Error("unwant hijacker of the writer which is not a hijacker")
+		}
+	}))
+
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if !rec.Flushed {
+		t.Error("want flushed response")
+	}
+
+	h = a.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := w.(http.Flusher); ok {
+			t.Error("unwant flusher of the writer which is not a flusher")
+		}
+		err := http.NewResponseController(w).Flush()
+		if !errors.Is(err, http.ErrNotSupported) {
+			t.Errorf("want not supported flush of the response controller, got: %v", err)
+		}
+	}))
+
+	h.ServeHTTP(struct{ http.ResponseWriter }{httptest.NewRecorder()}, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	buf.Reset()
+
+	h = a.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Fatalf("unwant hijack error: %s", err)
+		}
+		_ = conn.Close()
+	}))
+
+	h.ServeHTTP(hijackRecorder{httptest.NewRecorder()}, httptest.NewRequest(http.MethodGet, "/ws", nil))
+
+	ja := jsonassert.New(testprinter{t: t, link: line()})
+	ja.Assertf(buf.String(), `{
+		"message":"GET /ws 101",
+		"service":"api",
+		"level":"info",
+		"method":"GET",
+		"path":"/ws",
+		"status":101,
+		"bytes":0,
+		"duration":"1ms",
+		"remote_addr":"192.0.2.1:1234",
+		"user_agent":""
+	}`)
+}
+
+func TestAccessPanic(t *testing.T) {
+	var buf bytes.Buffer
+
+	a := plog.Access{
+		Log: &plog.Log{
+			Output: &buf,
+			Keys:   [4]encoding.TextMarshaler{pfmt.String("message")},
+		},
+		Since: func(time.Time) time.Duration { return time.Millisecond },
+	}
+
+	h := a.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("Hello,"))
+		panic("boom")
+	}))
+
+	func() {
+		defer func() {
+			if v := recover(); v != "boom" {
+				t.Errorf("want re-panic of the handler, got: %v", v)
+			}
+		}()
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}()
+
+	ja := jsonassert.New(testprinter{t: t, link: line()})
+	ja.Assertf(buf.String(), `{
+		"message":"GET / 500",
+		"level":"error",
+		"method":"GET",
+		"path":"/",
+		"status":500,
+		"bytes":6,
+		"duration":"1ms",
+		"remote_addr":"192.0.2.1:1234",
+		"user_agent":""
+	}`)
+}
@@ -0,0 +1,299 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plog
+
+import (
+	"context"
+	"encoding"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/pfmt/pfmt"
+)
+
+// Back-pressure policies of the asynchronous writer if buffer is full.
+const (
+	Block      = iota // Block waits for a free space in the buffer.
+	DropNewest        // DropNewest drops the record being written.
+	DropOldest        // DropOldest drops the oldest record of the buffer.
+	DropLevel         // DropLevel drops the record less severe than the severity threshold, blocks otherwise.
+)
+
+// ErrClosed is returned by writes to the closed writer.
+var ErrClosed = errors.New("plog: writer closed")
+
+// Async is an asynchronous writer with the bounded ring buffer of records
+// and the single goroutine which writes records to the output.
+type Async struct {
+	Output   io.Writer              // Output is a destination for output.
+	Size     int                    // Size is a capacity of the buffer in records, 1024 if zero.
+	Policy   int                    // Policy is a back-pressure policy if buffer is full.
+	Level    encoding.TextMarshaler // Level is a key of the severity level for the DropLevel policy, "level" if nil.
+	Severity int                    // Severity is a syslog severity threshold for the DropLevel policy, less severe records (greater numbers) are dropped.
+	Report   time.Duration          // Report is an interval of the dropped records report, reports are off if zero.
+	Encoder  Encoder                // Encoder encodes the dropped records report.
+
+	once    sync.Once
+	mu      sync.Mutex
+	cond    *sync.Cond
+	buf     [][]byte
+	head    int
+	n       int
+	busy    bool
+	closed  bool
+	dropped uint64
+	err     error
+	wmu     sync.Mutex
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+func (a *Async) init() {
+	a.once.Do(func() {
+		size := a.Size
+		if size <= 0 {
+			size = 1024
+		}
+		a.buf = make([][]byte, size)
+		a.cond = sync.NewCond(&a.mu)
+		a.stop = make(chan struct{})
+		a.done = make(chan struct{})
+
+		go a.flusher()
+
+		if a.Report > 0 {
+			go a.reporter()
+		}
+	})
+}
+
+// Write implements io.Writer. Copies the record into the buffer.
+func (a *Async) Write(p []byte) (int, error) {
+	a.init()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.closed {
+		return 0, ErrClosed
+	}
+
+	for a.n == len(a.buf) {
+		switch a.Policy {
+		case DropNewest:
+			a.dropped++
+			return len(p), nil
+
+		case DropOldest:
+			a.buf[a.head] = nil
+			a.head = (a.head + 1) % len(a.buf)
+			a.n--
+			a.dropped++
+
+		case DropLevel:
+			if a.severity(p) > a.Severity {
+				a.dropped++
+				return len(p), nil
+			}
+			a.cond.Wait()
+
+		default:
+			a.cond.Wait()
+		}
+
+		if a.closed {
+			return 0, ErrClosed
+		}
+	}
+
+	a.buf[(a.head+a.n)%len(a.buf)] = append([]byte(nil), p...)
+	a.n++
+	a.cond.Broadcast()
+
+	return len(p), nil
+}
+
+// severity returns severity number of the record, debug if record has no level.
+func (a *Async) severity(p []byte) int {
+	key := "level"
+	if a.Level != nil {
+		k, err := a.Level.MarshalText()
+		if err == nil {
+			key = string(k)
+		}
+	}
+
+	sev, ok := severity(jsoniter.Get(p, key).ToString())
+	if !ok {
+		return 7
+	}
+	return sev
+}
+
+// Dropped returns number of the dropped records.
+func (a *Async) Dropped() uint64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.dropped
+}
+
+// Flush waits until all buffered records are written to the output,
+// flushes the output and returns the last output error if any.
+func (a *Async) Flush(ctx context.Context) error {
+	a.init()
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			a.mu.Lock()
+			a.cond.Broadcast()
+			a.mu.Unlock()
+		case <-done:
+		}
+	}()
+
+	a.mu.Lock()
+	for a.n != 0 || a.busy {
+		if ctx.Err() != nil {
+			a.mu.Unlock()
+			return ctx.Err()
+		}
+		a.cond.Wait()
+	}
+	err := a.err
+	a.err = nil
+	a.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	return flush(ctx, a.Output)
+}
+
+// Close flushes buffered records and stops the writer goroutine.
+func (a *Async) Close(ctx context.Context) error {
+	a.init()
+
+	err := a.Flush(ctx)
+
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.stop)
+		a.cond.Broadcast()
+	}
+	a.mu.Unlock()
+
+	select {
+	case <-a.done:
+	case <-ctx.Done():
+		if err == nil {
+			err = ctx.Err()
+		}
+	}
+
+	return err
+}
+
+func (a *Async) flusher() {
+	defer close(a.done)
+
+	for {
+		a.mu.Lock()
+		for a.n == 0 && !a.closed {
+			a.cond.Wait()
+		}
+		if a.n == 0 && a.closed {
+			a.mu.Unlock()
+			return
+		}
+
+		p := a.buf[a.head]
+		a.buf[a.head] = nil
+		a.head = (a.head + 1) % len(a.buf)
+		a.n--
+		a.busy = true
+		a.cond.Broadcast()
+		a.mu.Unlock()
+
+		err := a.write(p)
+
+		a.mu.Lock()
+		a.busy = false
+		if err != nil {
+			a.err = err
+		}
+		a.cond.Broadcast()
+		a.mu.Unlock()
+	}
+}
+
+func (a *Async) write(p []byte) error {
+	a.wmu.Lock()
+	defer a.wmu.Unlock()
+
+	_, err := a.Output.Write(p)
+	return err
+}
+
+// reporter periodically writes the number of the dropped records since the last report.
+func (a *Async) reporter() {
+	t := time.NewTicker(a.Report)
+	defer t.Stop()
+
+	var reported uint64
+
+	for {
+		select {
+		case <-a.stop:
+			return
+		case <-t.C:
+		}
+
+		a.mu.Lock()
+		n := a.dropped - reported
+		reported = a.dropped
+		a.mu.Unlock()
+
+		if n == 0 {
+			continue
+		}
+
+		kv := []pfmt.KV{
+			StringLevel("level", "warning"),
+			StringString("message", "plog: dropped records"),
+			StringUint64("dropped", n),
+		}
+
+		var p []byte
+		if a.Encoder != nil {
+			p = a.Encoder.Encode(kv...)
+		} else {
+			m := make(map[string]pfmt.KV, len(kv))
+			for _, x := range kv {
+				k, _ := x.MarshalText()
+				m[string(k)] = x
+			}
+			p, _ = jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(m)
+		}
+		if p == nil {
+			continue
+		}
+
+		err := a.write(append(p, '\n'))
+		if err != nil {
+			a.mu.Lock()
+			a.err = err
+			a.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plog_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pfmt/plog"
+)
+
+// gateWriter blocks writes until the gate is opened.
+type gateWriter struct {
+	gate    chan struct{}
+	entered chan struct{}
+	mu      sync.Mutex
+	buf     bytes.Buffer
+}
+
+func newGateWriter() *gateWriter {
+	return &gateWriter{gate: make(chan struct{}), entered: make(chan struct{}, 1)}
+}
+
+func (w *gateWriter) Write(p []byte) (int, error) {
+	select {
+	case w.entered <- struct{}{}:
+	default:
+	}
+	<-w.gate
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *gateWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func TestAsyncPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		policy  int
+		input   []string
+		want    string
+		dropped uint64
+	}{
+		{
+			name:   "drop newest",
+			line:   line(),
+			policy: plog.DropNewest,
+			input: []string{
+				`{"message":"1"}`, `{"message":"2"}`, `{"message":"3"}`, `{"message":"4"}`,
+			},
+			// First record is taken by the writer goroutine,
+			// next two are buffered and the last is dropped.
+			want:    `{"message":"1"}{"message":"2"}{"message":"3"}`,
+			dropped: 1,
+		}, {
+			name:   "drop oldest",
+			line:   line(),
+			policy: plog.DropOldest,
+			input: []string{
+				`{"message":"1"}`, `{"message":"2"}`, `{"message":"3"}`, `{"message":"4"}`,
+			},
+			want:    `{"message":"1"}{"message":"3"}{"message":"4"}`,
+			dropped: 1,
+		}, {
+			name:   "drop level",
+			line:   line(),
+			policy: plog.DropLevel,
+			input: []string{
+				`{"message":"1"}`, `{"message":"2"}`, `{"message":"3"}`, `{"level":"debug","message":"4"}`,
+			},
+			want:    `{"message":"1"}{"message":"2"}{"message":"3"}`,
+			dropped: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.line+"/"+tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			w := newGateWriter()
+			a := &plog.Async{Output: w, Size: 2, Policy: tt.policy, Severity: 6}
+
+			for i, s := range tt.input {
+				_, err := a.Write([]byte(s))
+				if err != nil {
+					t.Fatalf("unwant write error: %s", err)
+				}
+				if i == 0 {
+					// Waits for the writer goroutine takes the first record.
+					<-w.entered
+				}
+			}
+
+			close(w.gate)
+
+			err := a.Close(context.Background())
+			if err != nil {
+				t.Fatalf("unwant close error: %s", err)
+			}
+
+			if w.String() != tt.want {
+				t.Errorf("\nwant: %s\n got: %s\ntest: %s", tt.want, w.String(), tt.line)
+			}
+
+			if a.Dropped() != tt.dropped {
+				t.Errorf("want %d dropped, got: %d", tt.dropped, a.Dropped())
+			}
+		})
+	}
+}
+
+func TestAsyncBlock(t *testing.T) {
+	w := newGateWriter()
+	a := &plog.Async{Output: w, Size: 1}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 3; i++ {
+			_, err := a.Write([]byte(`{"message":"Hello, World!"}` + "\n"))
+			if err != nil {
+				t.Errorf("unwant write error: %s", err)
+			}
+		}
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("want blocked write")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	close(w.gate)
+	<-done
+
+	err := a.Flush(context.Background())
+	if err != nil {
+		t.Fatalf("unwant flush error: %s", err)
+	}
+
+	if n := strings.Count(w.String(), "\n"); n != 3 {
+		t.Errorf("want 3 records, got: %d", n)
+	}
+
+	err = a.Close(context.Background())
+	if err != nil {
+		t.Fatalf("unwant close error: %s", err)
+	}
+
+	_, err = a.Write([]byte(`{}`))
+	if err != plog.ErrClosed {
+		t.Errorf("want closed error, got: %v", err)
+	}
+}
+
+func TestAsyncReport(t *testing.T) {
+	w := newGateWriter()
+
+	a := &plog.Async{Output: w, Size: 1, Policy: plog.DropNewest, Report: time.Millisecond}
+	defer a.Close(context.Background())
+
+	_, _ = a.Write([]byte(`{}` + "\n"))
+	<-w.entered
+
+	for i := 0; i < 4; i++ {
+		_, _ = a.Write([]byte(`{}` + "\n"))
+	}
+
+	close(w.gate)
+
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if strings.Contains(w.String(), `"dropped":`) {
+			return
+		}
+	}
+
+	t.Errorf("want dropped records report, got: %s", w.String())
+}
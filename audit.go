@@ -0,0 +1,231 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plog
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"sync"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+var (
+	// ErrChain is returned by the audit verifier if hash of the record
+	// does not match the previous hash and the record, so the record
+	// is edited or the previous record is deleted.
+	ErrChain = errors.New("plog: audit hash chain broken")
+	// ErrSequence is returned by the audit verifier if sequence number
+	// of the record does not follow the previous one.
+	ErrSequence = errors.New("plog: audit sequence broken")
+	// ErrSignature is returned by the audit verifier if signature of the checkpoint is invalid or missing.
+	ErrSignature = errors.New("plog: audit checkpoint signature invalid")
+	// ErrUnsigned is returned by the end of the audit verification if the last records
+	// follow the last signed checkpoint, so the end of the chain may be truncated.
+	ErrUnsigned = errors.New("plog: audit records after the last checkpoint unsigned")
+	// ErrUnchained is returned by the audit verifier if record has no sequence number or hash.
+	ErrUnchained = errors.New("plog: audit record without hash")
+)
+
+// Audit is a tamper-evident hash chain of the records.
+// Each record carries the sequence number and the SHA-256 hash
+// of the previous hash and the record with the sequence number,
+// every Checkpoint record carries the ed25519 signature of its hash.
+// Audit is shared by the tee'd loggers, so records are chained in the order of writes.
+type Audit struct {
+	Key        ed25519.PrivateKey // Key signs checkpoints, no checkpoints if nil.
+	Checkpoint int                // Checkpoint is a number of records between checkpoints, 1000 if zero.
+	Keys       [3]string          // Keys: 0 = sequence number, "seq" if empty; 1 = hash, "hash" if empty; 2 = checkpoint signature, "sig" if empty.
+
+	mu   sync.Mutex
+	seq  uint64
+	prev [sha256.Size]byte
+}
+
+// Resume continues the chain after the record of the sequence number
+// and the hash, for example after restart of the application.
+func (a *Audit) Resume(seq uint64, hash []byte) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.seq = seq
+	copy(a.prev[:], hash)
+}
+
+// chain appends sequence number, hash and checkpoint signature to the JSON record.
+// Caller holds the lock until record is written.
+func (a *Audit) chain(p []byte) []byte {
+	keys := auditKeys(a.Keys)
+
+	a.seq++
+
+	p = appendField(p, strconv.Quote(keys[0])+":"+strconv.FormatUint(a.seq, 10))
+
+	h := sha256.New()
+	h.Write(a.prev[:])
+	h.Write(p)
+	h.Sum(a.prev[:0])
+
+	p = appendField(p, strconv.Quote(keys[1])+":"+strconv.Quote(hex.EncodeToString(a.prev[:])))
+
+	checkpoint := a.Checkpoint
+	if checkpoint <= 0 {
+		checkpoint = 1000
+	}
+
+	if a.Key != nil && a.seq%uint64(checkpoint) == 0 {
+		sig := ed25519.Sign(a.Key, a.prev[:])
+		p = appendField(p, strconv.Quote(keys[2])+":"+strconv.Quote(base64.StdEncoding.EncodeToString(sig)))
+	}
+
+	return p
+}
+
+// appendField appends the field to the JSON object.
+func appendField(p []byte, field string) []byte {
+	obj := p[:len(p)-1]
+	if len(bytes.TrimSpace(obj)) > 1 {
+		obj = append(obj, ',')
+	}
+	return append(obj, field+"}"...)
+}
+
+func auditKeys(keys [3]string) [3]string {
+	if keys[0] == "" {
+		keys[0] = "seq"
+	}
+	if keys[1] == "" {
+		keys[1] = "hash"
+	}
+	if keys[2] == "" {
+		keys[2] = "sig"
+	}
+	return keys
+}
+
+// AuditVerifier verifies the hash chain of the audit records.
+type AuditVerifier struct {
+	Public     ed25519.PublicKey // Public verifies signatures of the checkpoints, not verified if nil.
+	Checkpoint int               // Checkpoint is a number of records between checkpoints, 1000 if zero.
+	Keys       [3]string         // Keys: 0 = sequence number, "seq" if empty; 1 = hash, "hash" if empty; 2 = checkpoint signature, "sig" if empty.
+	Anchor     bool              // Anchor trusts the first record as an anchor of the chain, for example first record of the rotated file without the previous files.
+
+	Seq    uint64 // Seq is a sequence number of the last verified record.
+	Hash   []byte // Hash is a hash of the last verified record.
+	Signed uint64 // Signed is a sequence number of the last verified checkpoint.
+}
+
+// Verify scans the lines of the reader and returns number of the first
+// broken line starting from 1 and error of the verification.
+// Chain starts with the zero hash of the sequence number 1
+// or with the Seq and Hash of the previous file if the verifier is resumed,
+// the first record of the other sequence number is trusted as an anchor
+// of the chain only if Anchor is set, otherwise deleted head of the chain
+// is reported as ErrSequence.
+// If the public key is set, each Checkpoint record requires the valid signature,
+// so the chain recomputed without the key is reported as ErrSignature.
+// Verifier keeps Seq and Hash of the last verified record
+// and Signed of the last verified checkpoint.
+func (v *AuditVerifier) Verify(r io.Reader) (int, error) {
+	keys := auditKeys(v.Keys)
+
+	checkpoint := v.Checkpoint
+	if checkpoint <= 0 {
+		checkpoint = 1000
+	}
+
+	re := regexp.MustCompile(`,?` + regexp.QuoteMeta(strconv.Quote(keys[1])) + `:"([0-9a-f]{64})"` +
+		`(?:,` + regexp.QuoteMeta(strconv.Quote(keys[2])) + `:"([A-Za-z0-9+/]+={0,2})")?[,}]`)
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<26)
+
+	var n int
+
+	for sc.Scan() {
+		n++
+
+		line := bytes.TrimRight(sc.Bytes(), "\r")
+
+		m := re.FindAllSubmatchIndex(line, -1)
+		if len(m) == 0 {
+			return n, ErrUnchained
+		}
+		loc := m[len(m)-1]
+
+		rec := append(append([]byte(nil), line[:loc[0]]...), '}')
+
+		var fields map[string]jsoniter.RawMessage
+		err := jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(rec, &fields)
+		if err != nil {
+			return n, ErrChain
+		}
+
+		seq, err := strconv.ParseUint(string(fields[keys[0]]), 10, 64)
+		if err != nil {
+			return n, ErrUnchained
+		}
+
+		hash, _ := hex.DecodeString(string(line[loc[2]:loc[3]]))
+
+		prev := v.Hash
+		if v.Seq == 0 && seq == 1 {
+			prev = make([]byte, sha256.Size)
+		}
+
+		if v.Seq == 0 && seq != 1 && !v.Anchor {
+			return n, ErrSequence
+		}
+
+		if v.Seq != 0 || seq == 1 {
+			if seq != v.Seq+1 {
+				return n, ErrSequence
+			}
+
+			h := sha256.New()
+			h.Write(prev)
+			h.Write(rec)
+			if !bytes.Equal(h.Sum(nil), hash) {
+				return n, ErrChain
+			}
+		}
+
+		if v.Public != nil {
+			if loc[4] == -1 && seq%uint64(checkpoint) == 0 {
+				return n, ErrSignature
+			}
+
+			if loc[4] != -1 {
+				sig, err := base64.StdEncoding.DecodeString(string(line[loc[4]:loc[5]]))
+				if err != nil || !ed25519.Verify(v.Public, hash, sig) {
+					return n, ErrSignature
+				}
+				v.Signed = seq
+			}
+		}
+
+		v.Seq = seq
+		v.Hash = hash
+	}
+
+	return 0, sc.Err()
+}
+
+// End returns ErrUnsigned if the public key is set and the last verified records
+// follow the last signed checkpoint, so the records after the checkpoint
+// may be truncated or replaced. Intends to be called after the last file of the chain.
+func (v *AuditVerifier) End() error {
+	if v.Public != nil && v.Seq != v.Signed {
+		return ErrUnsigned
+	}
+	return nil
+}
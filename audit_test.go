@@ -0,0 +1,159 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plog_test
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding"
+	"encoding/hex"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/pfmt/pfmt"
+	"github.com/pfmt/plog"
+)
+
+func TestAudit(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("unwant generate key error: %s", err)
+	}
+
+	var buf bytes.Buffer
+
+	l := &plog.Log{
+		Output: &buf,
+		Keys:   [4]encoding.TextMarshaler{pfmt.String("message")},
+		Audit:  &plog.Audit{Key: private, Checkpoint: 2},
+	}
+
+	for i := 1; i <= 5; i++ {
+		_, err := l.Write([]byte("record " + strconv.Itoa(i)))
+		if err != nil {
+			t.Fatalf("unwant write error: %s", err)
+		}
+	}
+
+	lines := strings.SplitAfter(strings.TrimSuffix(buf.String(), "\n"), "\n")
+
+	if !strings.Contains(lines[1], `"sig":"`) || strings.Contains(lines[2], `"sig":"`) {
+		t.Fatalf("want checkpoint of the second record only: %q", lines[1:3])
+	}
+
+	tests := []struct {
+		name   string
+		line   string
+		input  string
+		anchor bool
+		want   error
+		at     int
+		end    error
+	}{
+		{
+			name:  "intact chain",
+			line:  line(),
+			input: buf.String(),
+			end:   plog.ErrUnsigned,
+		}, {
+			name:  "chain ends by checkpoint",
+			line:  line(),
+			input: lines[0] + lines[1] + lines[2] + lines[3],
+		}, {
+			name:  "edited record",
+			line:  line(),
+			input: lines[0] + strings.Replace(lines[1], "record 2", "record 9", 1) + lines[2],
+			want:  plog.ErrChain,
+			at:    2,
+		}, {
+			name:  "deleted record",
+			line:  line(),
+			input: lines[0] + lines[2] + lines[3],
+			want:  plog.ErrSequence,
+			at:    2,
+		}, {
+			name:  "forged record",
+			line:  line(),
+			input: lines[0] + `{"message":"forged"}` + "\n" + lines[1],
+			want:  plog.ErrUnchained,
+			at:    2,
+		}, {
+			name:  "forged checkpoint signature",
+			line:  line(),
+			input: lines[0] + strings.Replace(lines[1], `"sig":"`, `"sig":"AA`, 1),
+			want:  plog.ErrSignature,
+			at:    2,
+		}, {
+			name:  "stripped signatures of the recomputed chain",
+			line:  line(),
+			input: rechain(t, lines),
+			want:  plog.ErrSignature,
+			at:    2,
+		}, {
+			name:  "deleted head",
+			line:  line(),
+			input: lines[2] + lines[3] + lines[4],
+			want:  plog.ErrSequence,
+			at:    1,
+		}, {
+			name:   "rotated file is anchored by the first record",
+			line:   line(),
+			input:  lines[2] + lines[3] + lines[4],
+			anchor: true,
+			end:    plog.ErrUnsigned,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.line+"/"+tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			v := plog.AuditVerifier{Public: public, Checkpoint: 2, Anchor: tt.anchor}
+
+			n, err := v.Verify(strings.NewReader(tt.input))
+			if !errors.Is(err, tt.want) || n != tt.at {
+				t.Errorf("%s: want %v at line %d, get %v at line %d", tt.line, tt.want, tt.at, err, n)
+			}
+
+			if tt.want == nil {
+				err = v.End()
+				if !errors.Is(err, tt.end) {
+					t.Errorf("%s: want end %v, get %v", tt.line, tt.end, err)
+				}
+			}
+		})
+	}
+}
+
+// rechain strips signatures of the records and recomputes the hash chain,
+// as if the records are forged without the key.
+func rechain(t *testing.T, lines []string) string {
+	re := regexp.MustCompile(`,"hash":"[0-9a-f]{64}"(,"sig":"[^"]*")?}`)
+
+	var b strings.Builder
+	prev := make([]byte, sha256.Size)
+
+	for _, line := range lines {
+		loc := re.FindStringIndex(line)
+		if loc == nil {
+			t.Fatalf("want chained record: %q", line)
+		}
+		rec := line[:loc[0]] + "}"
+
+		h := sha256.New()
+		h.Write(prev)
+		h.Write([]byte(rec))
+		prev = h.Sum(nil)
+
+		b.WriteString(line[:loc[0]] + `,"hash":"` + hex.EncodeToString(prev) + `"}` + "\n")
+	}
+
+	return b.String()
+}
@@ -0,0 +1,75 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Plogaudit verifies the hash chain of the audit log files
+// and reports the first broken record.
+//
+// Usage:
+//
+//	plogaudit [-pub base64-ed25519-public-key] [-checkpoint n] [-anchor] file...
+//
+// Files are verified as one chain in the order of arguments,
+// for example rotated backups followed by the current file.
+// Chain must start with the sequence number 1 unless -anchor is set,
+// which trusts the first record as an anchor of the chain,
+// for example if the oldest rotated files are removed.
+// If -pub is set, every -checkpoint record must be signed
+// and the records after the last signed checkpoint are reported,
+// so the truncated end of the chain is visible.
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/pfmt/plog"
+)
+
+func main() {
+	pub := flag.String("pub", "", "base64 encoded ed25519 public key of the checkpoints")
+	checkpoint := flag.Int("checkpoint", 1000, "number of records between checkpoints")
+	anchor := flag.Bool("anchor", false, "trust the first record as an anchor of the chain")
+	flag.Parse()
+
+	v := plog.AuditVerifier{Checkpoint: *checkpoint, Anchor: *anchor}
+
+	if *pub != "" {
+		key, err := base64.StdEncoding.DecodeString(*pub)
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			fmt.Fprintln(os.Stderr, "plogaudit: invalid public key")
+			os.Exit(2)
+		}
+		v.Public = key
+	}
+
+	for _, name := range flag.Args() {
+		f, err := os.Open(name)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "plogaudit:", err)
+			os.Exit(2)
+		}
+
+		n, err := v.Verify(f)
+		f.Close()
+
+		if err != nil && n != 0 {
+			fmt.Printf("%s:%d: %s\n", name, n, err)
+			os.Exit(1)
+		} else if err != nil {
+			fmt.Fprintln(os.Stderr, "plogaudit:", err)
+			os.Exit(2)
+		}
+	}
+
+	fmt.Printf("ok: last sequence number %d\n", v.Seq)
+
+	if v.End() != nil && v.Signed == 0 {
+		fmt.Println("unsigned: no signed checkpoint")
+	} else if v.End() != nil {
+		fmt.Printf("unsigned: %d records after the last signed checkpoint %d\n", v.Seq-v.Signed, v.Signed)
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plog
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pfmt/pfmt"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type contextKey struct{}
+
+// NewContext returns copy of the context which carries the logger.
+func NewContext(ctx context.Context, l Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger of the context
+// or the logger without output if context does not carry one.
+func FromContext(ctx context.Context) Logger {
+	if l, ok := ctx.Value(contextKey{}).(Logger); ok {
+		return l
+	}
+	return &Log{}
+}
+
+// Extractor returns key-values of the context, for example request ID.
+type Extractor func(ctx context.Context) []pfmt.KV
+
+var (
+	extractorsMu sync.RWMutex
+	extractors   []Extractor
+)
+
+// RegisterExtractor registers extractors of the key-values of the context.
+// Extracted key-values of the later extractors have a higher priority.
+func RegisterExtractor(ex ...Extractor) {
+	extractorsMu.Lock()
+	defer extractorsMu.Unlock()
+	extractors = append(extractors, ex...)
+}
+
+// Extract returns key-values of the context extracted by the registered extractors.
+func Extract(ctx context.Context) []pfmt.KV {
+	extractorsMu.RLock()
+	defer extractorsMu.RUnlock()
+
+	var kv []pfmt.KV
+	for _, ex := range extractors {
+		kv = append(kv, ex(ctx)...)
+	}
+	return kv
+}
+
+// ContextValue returns extractor of the value of the context key.
+func ContextValue(key string, ctxKey interface{}) Extractor {
+	return func(ctx context.Context) []pfmt.KV {
+		switch v := ctx.Value(ctxKey).(type) {
+		case nil:
+			return nil
+		case string:
+			return []pfmt.KV{StringString(key, v)}
+		case fmt.Stringer:
+			return []pfmt.KV{StringString(key, v.String())}
+		default:
+			return []pfmt.KV{StringAny(key, v)}
+		}
+	}
+}
+
+// ContextDeadline returns extractor of the time remaining until the deadline of the context.
+func ContextDeadline(key string) Extractor {
+	return func(ctx context.Context) []pfmt.KV {
+		d, ok := ctx.Deadline()
+		if !ok {
+			return nil
+		}
+		return []pfmt.KV{StringDuration(key, time.Until(d))}
+	}
+}
+
+// Context returns copy of the logger with the key-values extracted from the context
+// and the trace fields of the active OpenTelemetry span of the context.
+// Extracted key-values have the priority of the key-values of the Tee.
+func (l *Log) Context(ctx context.Context) Logger {
+	t := l.Trace
+	if t == nil {
+		t = &Trace{}
+	}
+
+	l0 := l.Tee(append(t.extract(ctx), Extract(ctx)...)...).(*Log)
+	if trace.SpanFromContext(ctx).IsRecording() {
+		l0.ctx = ctx
+	}
+	return l0
+}
+
+// DebugContext writes debug message by the logger of the context.
+func DebugContext(ctx context.Context, msg string, kv ...pfmt.KV) error {
+	return logContext(ctx, "debug", msg, kv)
+}
+
+// InfoContext writes informational message by the logger of the context.
+func InfoContext(ctx context.Context, msg string, kv ...pfmt.KV) error {
+	return logContext(ctx, "info", msg, kv)
+}
+
+// WarnContext writes warning message by the logger of the context.
+func WarnContext(ctx context.Context, msg string, kv ...pfmt.KV) error {
+	return logContext(ctx, "warning", msg, kv)
+}
+
+// ErrorContext writes error message by the logger of the context.
+func ErrorContext(ctx context.Context, msg string, kv ...pfmt.KV) error {
+	return logContext(ctx, "error", msg, kv)
+}
+
+// logContext writes message of the severity level by the logger of the context
+// with the extracted key-values and the key-values of the call,
+// key-values of the call have the highest priority.
+func logContext(ctx context.Context, level, msg string, kv []pfmt.KV) error {
+	l := FromContext(ctx)
+
+	// Severity level goes first, so the Level function of the logger selects the output.
+	x := []pfmt.KV{levelKV(l, level)}
+
+	if c, ok := l.(interface{ Context(context.Context) Logger }); ok {
+		l = c.Context(ctx)
+		defer l.Close()
+	} else {
+		x = append(x, Extract(ctx)...)
+	}
+
+	l = l.Tee(append(x, kv...)...)
+	defer l.Close()
+
+	_, err := l.Write([]byte(msg))
+	return err
+}
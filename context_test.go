@@ -0,0 +1,163 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plog_test
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/kinbiko/jsonassert"
+	"github.com/pfmt/pfmt"
+	"github.com/pfmt/plog"
+)
+
+type requestIDKey struct{}
+
+type userIDKey struct{}
+
+func TestContext(t *testing.T) {
+	plog.RegisterExtractor(
+		plog.ContextValue("request_id", requestIDKey{}),
+		plog.ContextValue("user_id", userIDKey{}),
+		plog.ContextDeadline("deadline_remaining"),
+	)
+
+	var buf, errBuf bytes.Buffer
+
+	l := &plog.Log{
+		Output: &buf,
+		Keys:   [4]encoding.TextMarshaler{pfmt.String("message")},
+		KV:     []pfmt.KV{plog.StringString("service", "api"), plog.StringString("user_id", "anonymous")},
+		Level: func(level string) io.Writer {
+			if level == "error" {
+				return &errBuf
+			}
+			return nil
+		},
+	}
+
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "req-1")
+	ctx = context.WithValue(ctx, userIDKey{}, 42)
+	ctx = plog.NewContext(ctx, l)
+
+	if plog.FromContext(ctx) != plog.Logger(l) {
+		t.Fatal("want logger of the context")
+	}
+
+	err := plog.InfoContext(ctx, "Hello, World!", plog.StringString("request_id", "req-2"))
+	if err != nil {
+		t.Fatalf("unwant write error: %s", err)
+	}
+
+	ja := jsonassert.New(testprinter{t: t, link: line()})
+	ja.Assertf(buf.String(), `{
+		"message":"Hello, World!",
+		"service":"api",
+		"level":"info",
+		"request_id":"req-2",
+		"user_id":42
+	}`)
+
+	ctx, cancel := context.WithTimeout(ctx, time.Hour)
+	defer cancel()
+
+	err = plog.ErrorContext(ctx, "Bye!")
+	if err != nil {
+		t.Fatalf("unwant write error: %s", err)
+	}
+
+	ja = jsonassert.New(testprinter{t: t, link: line()})
+	ja.Assertf(errBuf.String(), `{
+		"message":"Bye!",
+		"service":"api",
+		"level":"error",
+		"request_id":"req-1",
+		"user_id":42,
+		"deadline_remaining":"<<PRESENCE>>"
+	}`)
+
+	buf.Reset()
+
+	_, err = l.Context(ctx).Write([]byte("Hi!"))
+	if err != nil {
+		t.Fatalf("unwant write error: %s", err)
+	}
+
+	ja = jsonassert.New(testprinter{t: t, link: line()})
+	ja.Assertf(buf.String(), `{
+		"message":"Hi!",
+		"service":"api",
+		"request_id":"req-1",
+		"user_id":42,
+		"deadline_remaining":"<<PRESENCE>>"
+	}`)
+
+	// Logger without output.
+	err = plog.InfoContext(context.Background(), "Nowhere")
+	if err != nil {
+		t.Fatalf("unwant write error: %s", err)
+	}
+}
+
+func TestContextLevelKey(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		log  func(w io.Writer) *plog.Log
+		want string
+	}{
+		{
+			name: "level key",
+			line: line(),
+			log: func(w io.Writer) *plog.Log {
+				return &plog.Log{
+					Output:   w,
+					Keys:     [4]encoding.TextMarshaler{pfmt.String("message")},
+					LevelKey: pfmt.String("severity"),
+				}
+			},
+			want: `{
+				"message":"Hello, World!",
+				"severity":"warning"
+			}`,
+		}, {
+			name: "GELF numeric level",
+			line: line(),
+			log: func(w io.Writer) *plog.Log {
+				l := plog.GELF()
+				l.Output = w
+				l.KV = nil
+				return l
+			},
+			want: `{
+				"short_message":"Hello, World!",
+				"level":4
+			}`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.line+"/"+tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+
+			ctx := plog.NewContext(context.Background(), tt.log(&buf))
+
+			err := plog.WarnContext(ctx, "Hello, World!")
+			if err != nil {
+				t.Fatalf("unwant write error: %s", err)
+			}
+
+			ja := jsonassert.New(testprinter{t: t, link: tt.line})
+			ja.Assertf(buf.String(), tt.want)
+		})
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plog
+
+import (
+	"bytes"
+	"context"
+	"hash/fnv"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// Dedup is a writer which folds consecutive identical records
+// into the summary record with the number of repeats, syslog style.
+// First record of the run is written immediately, repeats are suppressed
+// until the run of the identical records ends or the window since the first record
+// of the run expires, then the summary record is written
+// with the repeat_count, first_seen and last_seen keys if the record was repeated.
+// Errors of the summary records are reported by the Flush.
+type Dedup struct {
+	Output io.Writer     // Output is a destination for output.
+	Window time.Duration // Window is a maximum duration of the run, 1 second if zero.
+	Ignore []string      // Ignore is a keys excluded from the signature of the record, for example timestamp.
+	Keys   [3]string     // Keys: 0 = number of the suppressed repeats; 1 = first seen; 2 = last seen.
+
+	mu    sync.Mutex
+	held  []byte
+	sig   uint64
+	count int
+	first time.Time
+	last  time.Time
+	timer *time.Timer
+	gen   uint64
+	err   error
+}
+
+// Write implements io.Writer.
+func (d *Dedup) Write(p []byte) (int, error) {
+	sig, err := d.signature(p)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.held != nil && d.sig == sig {
+		d.count++
+		d.last = now
+		return len(p), nil
+	}
+
+	d.end()
+
+	n, err := d.Output.Write(p)
+	if err != nil {
+		return n, err
+	}
+
+	d.held = append(d.held[:0], p...)
+	d.sig = sig
+	d.count = 1
+	d.first = now
+	d.last = now
+
+	window := d.Window
+	if window <= 0 {
+		window = time.Second
+	}
+
+	d.gen++
+	gen := d.gen
+	d.timer = time.AfterFunc(window, func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		// Run may be ended and replaced by the next one already.
+		if d.held != nil && d.gen == gen {
+			d.end()
+		}
+	})
+
+	return n, nil
+}
+
+// Flush ends the run, writes the summary record and flushes the output.
+// Returns the first error of the summary records since the previous flush.
+func (d *Dedup) Flush(ctx context.Context) error {
+	d.mu.Lock()
+	d.end()
+	err := d.err
+	d.err = nil
+	d.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+	return flush(ctx, d.Output)
+}
+
+// end ends the run and writes the summary record if the record was repeated,
+// keeps the error of the summary record for the Flush.
+func (d *Dedup) end() {
+	if d.held == nil {
+		return
+	}
+
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+
+	p := d.held
+	d.held = nil
+
+	if d.count < 2 {
+		return
+	}
+
+	_, err := d.Output.Write(d.fold(p))
+	if err != nil && d.err == nil {
+		d.err = err
+	}
+}
+
+// fold appends repeat count and first/last seen times to the JSON object.
+func (d *Dedup) fold(p []byte) []byte {
+	keys := d.Keys
+	if keys[0] == "" {
+		keys[0] = "repeat_count"
+	}
+	if keys[1] == "" {
+		keys[1] = "first_seen"
+	}
+	if keys[2] == "" {
+		keys[2] = "last_seen"
+	}
+
+	rec := bytes.TrimRight(p, "\n")
+	if len(rec) == 0 || rec[len(rec)-1] != '}' {
+		return p
+	}
+
+	obj := bytes.TrimSpace(rec[:len(rec)-1])
+
+	var buf bytes.Buffer
+	buf.Write(obj)
+	if len(obj) > 1 {
+		buf.WriteByte(',')
+	}
+	buf.WriteString(strconv.Quote(keys[0]) + ":" + strconv.Itoa(d.count-1))
+	buf.WriteString("," + strconv.Quote(keys[1]) + ":" + strconv.Quote(d.first.Format(time.RFC3339Nano)))
+	buf.WriteString("," + strconv.Quote(keys[2]) + ":" + strconv.Quote(d.last.Format(time.RFC3339Nano)))
+	buf.WriteString("}")
+	buf.Write(p[len(rec):])
+
+	return buf.Bytes()
+}
+
+// signature returns hash of the record without ignored keys.
+func (d *Dedup) signature(p []byte) (uint64, error) {
+	h := fnv.New64a()
+
+	if len(d.Ignore) == 0 {
+		h.Write(bytes.TrimRight(p, "\n"))
+		return h.Sum64(), nil
+	}
+
+	var rec map[string]jsoniter.RawMessage
+	err := jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(p, &rec)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, k := range d.Ignore {
+		delete(rec, k)
+	}
+
+	// Map keys are sorted, so the signature does not depend on the order of keys.
+	b, err := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(rec)
+	if err != nil {
+		return 0, err
+	}
+
+	h.Write(b)
+	return h.Sum64(), nil
+}
@@ -0,0 +1,170 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plog_test
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pfmt/pfmt"
+	"github.com/pfmt/plog"
+)
+
+// syncBuffer is a bytes buffer safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestDedup(t *testing.T) {
+	var buf syncBuffer
+
+	d := &plog.Dedup{Output: &buf, Window: time.Hour, Ignore: []string{"timestamp"}}
+
+	l := &plog.Log{
+		Output: d,
+		Keys:   [4]encoding.TextMarshaler{pfmt.String("message")},
+		KV: []pfmt.KV{
+			plog.StringFunc("timestamp", func() pfmt.KV { return pfmt.Int64(time.Now().UnixNano()) }),
+		},
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l0 := l.Tee(plog.StringString("foo", "bar"))
+			defer l0.Close()
+			_, err := l0.Write([]byte("Hello, World!"))
+			if err != nil {
+				t.Errorf("unwant write error: %s", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	_, err := l.Write([]byte("Bye, World!"))
+	if err != nil {
+		t.Fatalf("unwant write error: %s", err)
+	}
+
+	err = d.Flush(context.Background())
+	if err != nil {
+		t.Fatalf("unwant flush error: %s", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("want first, summary and next records, got: %q", lines)
+	}
+
+	if strings.Contains(lines[0], "repeat_count") || !strings.Contains(lines[0], "Hello, World!") {
+		t.Errorf("want first record of the run as is, got: %s", lines[0])
+	}
+
+	var rec map[string]interface{}
+	err = json.Unmarshal([]byte(lines[1]), &rec)
+	if err != nil {
+		t.Fatalf("unwant unmarshal error: %s", err)
+	}
+
+	if rec["message"] != "Hello, World!" || rec["repeat_count"] != 2.0 || rec["foo"] != "bar" {
+		t.Errorf("want summary record, got: %s", lines[1])
+	}
+	if rec["first_seen"] == nil || rec["last_seen"] == nil {
+		t.Errorf("want first and last seen, got: %s", lines[1])
+	}
+
+	if strings.Contains(lines[2], "repeat_count") {
+		t.Errorf("unwant repeat count of the single record, got: %s", lines[2])
+	}
+}
+
+func TestDedupWindow(t *testing.T) {
+	var buf syncBuffer
+
+	d := &plog.Dedup{Output: &buf, Window: time.Millisecond}
+
+	for i := 0; i < 2; i++ {
+		_, err := d.Write([]byte(`{"message":"Hello, World!"}` + "\n"))
+		if err != nil {
+			t.Fatalf("unwant write error: %s", err)
+		}
+		if i == 0 && buf.String() != `{"message":"Hello, World!"}`+"\n" {
+			t.Fatalf("want first record written immediately, got: %q", buf.String())
+		}
+	}
+
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if strings.Contains(buf.String(), `"repeat_count":1`) {
+			return
+		}
+	}
+
+	t.Errorf("want folded record on window expiry, got: %q", buf.String())
+}
+
+// toggleWriter is a writer which fails if fail is set.
+type toggleWriter struct{ fail bool }
+
+func (w *toggleWriter) Write(p []byte) (int, error) {
+	if w.fail {
+		return 0, errors.New("disk full")
+	}
+	return len(p), nil
+}
+
+func TestDedupSummaryError(t *testing.T) {
+	w := &toggleWriter{}
+
+	d := &plog.Dedup{Output: w, Window: time.Hour}
+
+	for i := 0; i < 2; i++ {
+		_, err := d.Write([]byte(`{"message":"Hello, World!"}` + "\n"))
+		if err != nil {
+			t.Fatalf("unwant write error: %s", err)
+		}
+	}
+
+	w.fail = true
+
+	// Summary of the previous run fails, but the next record is unrelated.
+	_, err := d.Write([]byte(`{"message":"Bye, World!"}` + "\n"))
+	if err == nil || err.Error() != "disk full" {
+		t.Fatalf("want write error of the record itself, got: %v", err)
+	}
+
+	w.fail = false
+
+	_, err = d.Write([]byte(`{"message":"Hello again!"}` + "\n"))
+	if err != nil {
+		t.Fatalf("unwant write error of the summary of the previous run: %s", err)
+	}
+
+	err = d.Flush(context.Background())
+	if err == nil || err.Error() != "disk full" {
+		t.Errorf("want summary error of the flush, got: %v", err)
+	}
+}
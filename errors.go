@@ -0,0 +1,181 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plog
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/pfmt/pfmt"
+)
+
+// kve is a key and an error implements json/text marshaler.
+// Error is encoded as an object of the message, the type name,
+// the chain of the wrapped errors, the stack trace if error carries one
+// and the key-values of the errors which implement KeyValuer interface.
+type kve struct {
+	K encoding.TextMarshaler
+	V error
+}
+
+func (kv kve) MarshalText() (text []byte, err error) { return kv.K.MarshalText() }
+
+func (kv kve) MarshalJSON() ([]byte, error) {
+	if kv.V == nil {
+		return []byte("null"), nil
+	}
+	return kv.group(false).MarshalJSON()
+}
+
+// StringErrorObject encodes the error as an object,
+// unlike StringError which encodes the message of the error only.
+func StringErrorObject(k string, v error) kve {
+	return kve{K: pfmt.String(k), V: v}
+}
+
+// TextErrorObject encodes the error as an object,
+// unlike TextError which encodes the message of the error only.
+func TextErrorObject(k encoding.TextMarshaler, v error) kve {
+	return kve{K: k, V: v}
+}
+
+// group returns key-values of the error,
+// chain is joined by the new lines if flat.
+func (kv kve) group(flat bool) kvg {
+	g := kvg{K: kv.K}
+
+	// Stack trace wrappers are transparent.
+	root := kv.V
+	for s, ok := root.(*stackError); ok; s, ok = root.(*stackError) {
+		root = s.err
+	}
+
+	var chain []string
+	var merge []pfmt.KV
+	var stack []uintptr
+
+	walk(kv.V, func(err error, depth int) {
+		if _, ok := err.(*stackError); !ok && depth != 0 && err != root {
+			chain = append(chain, fmt.Sprintf("%T: %s", err, err))
+		}
+		if s := stackTrace(err); s != nil {
+			// The deepest stack trace is the closest to the origin of the error.
+			stack = s
+		}
+		if x, ok := err.(KeyValuer); ok {
+			// Key-values of the outer errors have a higher priority.
+			merge = append(append([]pfmt.KV(nil), x.KeyValues()...), merge...)
+		}
+	})
+
+	g.KV = append(g.KV, merge...)
+	g.KV = append(g.KV,
+		StringString("message", kv.V.Error()),
+		StringString("type", fmt.Sprintf("%T", root)),
+	)
+
+	if len(chain) != 0 {
+		if flat {
+			g.KV = append(g.KV, StringString("chain", strings.Join(chain, "\n")))
+		} else {
+			g.KV = append(g.KV, StringStrings("chain", chain))
+		}
+	}
+
+	if stack != nil {
+		g.KV = append(g.KV, StringString("stack", formatStack(stack)))
+	}
+
+	return g
+}
+
+// walk calls function for the error and the wrapped errors depth-first,
+// including errors joined by errors.Join.
+func walk(err error, f func(err error, depth int)) {
+	var visit func(err error, depth int)
+	visit = func(err error, depth int) {
+		if err == nil || depth > 100 {
+			return
+		}
+		f(err, depth)
+		switch x := err.(type) {
+		case interface{ Unwrap() []error }:
+			for _, e := range x.Unwrap() {
+				visit(e, depth+1)
+			}
+		default:
+			visit(errors.Unwrap(err), depth+1)
+		}
+	}
+	visit(err, 0)
+}
+
+// stackTrace returns program counters of the stack trace of the error
+// created by the WrapStack function or the github.com/pkg/errors package.
+func stackTrace(err error) []uintptr {
+	if s, ok := err.(*stackError); ok {
+		return s.pcs
+	}
+
+	// Method of the github.com/pkg/errors returns errors.StackTrace type,
+	// which is a slice of the program counters of the runtime.Callers.
+	m := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !m.IsValid() || m.Type().NumIn() != 0 || m.Type().NumOut() != 1 {
+		return nil
+	}
+
+	v := m.Call(nil)[0]
+	if v.Kind() != reflect.Slice || v.Type().Elem().Kind() != reflect.Uintptr {
+		return nil
+	}
+
+	pcs := make([]uintptr, v.Len())
+	for i := range pcs {
+		pcs[i] = uintptr(v.Index(i).Uint())
+	}
+	return pcs
+}
+
+// formatStack formats stack trace like the panic does.
+func formatStack(pcs []uintptr) string {
+	var b strings.Builder
+	frames := runtime.CallersFrames(pcs)
+	for {
+		f, more := frames.Next()
+		if f.Function != "" || f.File != "" {
+			b.WriteString(f.Function + "\n\t" + f.File + ":" + strconv.Itoa(f.Line) + "\n")
+		}
+		if !more {
+			break
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// stackError is an error with the stack trace.
+type stackError struct {
+	err error
+	pcs []uintptr
+}
+
+func (e *stackError) Error() string { return e.err.Error() }
+func (e *stackError) Unwrap() error { return e.err }
+
+// WrapStack returns error which wraps the error
+// and carries the stack trace of the caller.
+// Returns nil if error is nil.
+func WrapStack(err error) error {
+	if err == nil {
+		return nil
+	}
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+	return &stackError{err: err, pcs: pcs[:n]}
+}
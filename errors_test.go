@@ -0,0 +1,177 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plog_test
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/kinbiko/jsonassert"
+	"github.com/pfmt/pfmt"
+	"github.com/pfmt/plog"
+)
+
+// codeError is an error with the key-values.
+type codeError struct{ code int }
+
+func (e codeError) Error() string        { return fmt.Sprintf("code %d", e.code) }
+func (e codeError) KeyValues() []pfmt.KV { return []pfmt.KV{plog.StringInt("code", e.code)} }
+
+// frame and stackTrace mimic the github.com/pkg/errors types.
+type frame uintptr
+
+type stackTrace []frame
+
+type pkgError struct {
+	msg   string
+	stack stackTrace
+}
+
+func (e *pkgError) Error() string          { return e.msg }
+func (e *pkgError) StackTrace() stackTrace { return e.stack }
+
+func newPkgError(msg string) error {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(1, pcs)
+	st := make(stackTrace, n)
+	for i, pc := range pcs[:n] {
+		st[i] = frame(pc)
+	}
+	return &pkgError{msg: msg, stack: st}
+}
+
+func TestErrorObject(t *testing.T) {
+	pathErr := &fs.PathError{Op: "open", Path: "/etc/app.conf", Err: syscall.ENOENT}
+
+	tests := []struct {
+		name  string
+		line  string
+		log   *plog.Log
+		kv    pfmt.KV
+		want  string
+		stack string
+	}{
+		{
+			name: "wrapped chain",
+			line: line(),
+			kv:   plog.StringErrorObject("error", fmt.Errorf("read config: %w", pathErr)),
+			want: `{
+				"message":"Hello, World!",
+				"error":{
+					"message":"read config: open /etc/app.conf: no such file or directory",
+					"type":"*fmt.wrapError",
+					"chain":["*fs.PathError: open /etc/app.conf: no such file or directory","syscall.Errno: no such file or directory"]
+				}
+			}`,
+		}, {
+			name: "joined errors with key-values",
+			line: line(),
+			kv:   plog.StringErrorObject("error", errors.Join(errors.New("first"), codeError{code: 42})),
+			want: `{
+				"message":"Hello, World!",
+				"error":{
+					"message":"first\ncode 42",
+					"type":"*errors.joinError",
+					"chain":["*errors.errorString: first","plog_test.codeError: code 42"],
+					"code":42
+				}
+			}`,
+		}, {
+			name: "stack trace of the wrapper",
+			line: line(),
+			kv:   plog.StringErrorObject("error", plog.WrapStack(errors.New("boom"))),
+			want: `{
+				"message":"Hello, World!",
+				"error":{
+					"message":"boom",
+					"type":"*errors.errorString",
+					"stack":"<<PRESENCE>>"
+				}
+			}`,
+			stack: "plog_test.TestErrorObject",
+		}, {
+			name: "stack trace of the github.com/pkg/errors like error",
+			line: line(),
+			kv:   plog.StringErrorObject("error", fmt.Errorf("wrap: %w", newPkgError("boom"))),
+			want: `{
+				"message":"Hello, World!",
+				"error":{
+					"message":"wrap: boom",
+					"type":"*fmt.wrapError",
+					"chain":["*plog_test.pkgError: boom"],
+					"stack":"<<PRESENCE>>"
+				}
+			}`,
+			stack: "plog_test.newPkgError",
+		}, {
+			name: "nil error",
+			line: line(),
+			kv:   plog.StringErrorObject("error", nil),
+			want: `{
+				"message":"Hello, World!",
+				"error":null
+			}`,
+		}, {
+			name: "GELF additional fields",
+			line: line(),
+			log:  plog.GELF(),
+			kv:   plog.StringErrorObject("error", fmt.Errorf("read config: %w", pathErr)),
+			want: `{
+				"version":"1.1",
+				"timestamp":"<<PRESENCE>>",
+				"short_message":"Hello, World!",
+				"_error_message":"read config: open /etc/app.conf: no such file or directory",
+				"_error_type":"*fmt.wrapError",
+				"_error_chain":"*fs.PathError: open /etc/app.conf: no such file or directory\nsyscall.Errno: no such file or directory"
+			}`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.line+"/"+tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+
+			l := tt.log
+			if l == nil {
+				l = &plog.Log{Keys: [4]encoding.TextMarshaler{pfmt.String("message")}}
+			}
+			l.Output = &buf
+
+			_, err := l.Tee(tt.kv).Write([]byte("Hello, World!"))
+			if err != nil {
+				t.Fatalf("unwant write error: %s", err)
+			}
+
+			ja := jsonassert.New(testprinter{t: t, link: tt.line})
+			ja.Assertf(buf.String(), tt.want)
+
+			if tt.stack != "" {
+				var rec struct {
+					Error struct {
+						Stack string `json:"stack"`
+					} `json:"error"`
+				}
+				err = json.Unmarshal(buf.Bytes(), &rec)
+				if err != nil {
+					t.Fatalf("unwant unmarshal error: %s", err)
+				}
+				if !strings.Contains(rec.Error.Stack, tt.stack) {
+					t.Errorf("%s: want stack trace with %q, get %q", tt.line, tt.stack, rec.Error.Stack)
+				}
+			}
+		})
+	}
+}
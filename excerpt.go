@@ -0,0 +1,184 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plog
+
+import (
+	"bytes"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Strategies of the message excerpt.
+// Remainder of the message which is not in the excerpt
+// is written to the trail key if it is set.
+const (
+	ExcerptTruncate  = iota // ExcerptTruncate truncates whole message at the Trunc length.
+	ExcerptFirstLine        // ExcerptFirstLine takes the first non-blank line of the message.
+	ExcerptSentence         // ExcerptSentence takes the first sentence of the message.
+	ExcerptHeadTail         // ExcerptHeadTail keeps head and tail of the message of the Trunc length with the truncate mark in the middle.
+)
+
+// strategy splits the message text into the excerpt and the remainder.
+func (l *Log) strategy(text []byte) (excerpt, rest []byte) {
+	switch l.Strategy {
+	case ExcerptFirstLine:
+		return firstLine(text)
+	case ExcerptSentence:
+		return firstSentence(text)
+	case ExcerptHeadTail:
+		return l.headTail(text)
+	}
+	return text, nil
+}
+
+// firstLine returns the first non-blank line and the lines after it.
+func firstLine(text []byte) ([]byte, []byte) {
+	for len(text) != 0 {
+		i := bytes.IndexByte(text, '\n')
+		if i == -1 {
+			return text, nil
+		}
+
+		line := text[:i]
+		text = text[i+1:]
+
+		if len(bytes.TrimSpace(line)) != 0 {
+			return bytes.TrimRight(line, "\r"), trimRest(text)
+		}
+	}
+	return nil, nil
+}
+
+// firstSentence returns the first sentence and the text after it.
+// Sentence ends with the terminal punctuation followed by the white space
+// or the end of the text, or with the blank line.
+func firstSentence(text []byte) ([]byte, []byte) {
+	text = bytes.TrimLeftFunc(text, unicode.IsSpace)
+
+	for i := 0; i < len(text); {
+		r, n := utf8.DecodeRune(text[i:])
+
+		switch r {
+		case '.', '!', '?', '…':
+			// Consumes the run of the punctuation, for example "?!" or "...".
+			end := i + n
+			for end < len(text) {
+				r, n := utf8.DecodeRune(text[end:])
+				if r != '.' && r != '!' && r != '?' && r != '…' {
+					break
+				}
+				end += n
+			}
+
+			next, _ := utf8.DecodeRune(text[end:])
+			if end == len(text) || unicode.IsSpace(next) {
+				return text[:end], trimRest(bytes.TrimLeft(text[end:], " \t"))
+			}
+
+			i = end
+			continue
+
+		case '。', '！', '？':
+			return text[:i+n], trimRest(bytes.TrimLeft(text[i+n:], " \t"))
+
+		case '\n':
+			j := i + n
+			for j < len(text) && (text[j] == ' ' || text[j] == '\t' || text[j] == '\r') {
+				j++
+			}
+			if j < len(text) && text[j] == '\n' {
+				return bytes.TrimRightFunc(text[:i], unicode.IsSpace), trimRest(text[j:])
+			}
+		}
+
+		i += n
+	}
+
+	return text, nil
+}
+
+// trimRest rids of the leading blank lines and the trailing white space of the remainder,
+// keeping indentation of the first non-blank line.
+func trimRest(rest []byte) []byte {
+	for {
+		i := bytes.IndexByte(rest, '\n')
+		if i == -1 || len(bytes.TrimSpace(rest[:i])) != 0 {
+			break
+		}
+		rest = rest[i+1:]
+	}
+
+	rest = bytes.TrimRightFunc(rest, unicode.IsSpace)
+	if len(bytes.TrimSpace(rest)) == 0 {
+		return nil
+	}
+	return rest
+}
+
+// headTail returns head and tail of the text of the Trunc length
+// joined by the truncate mark or "…" if mark is empty,
+// and the middle of the text.
+func (l *Log) headTail(text []byte) ([]byte, []byte) {
+	text = bytes.TrimSpace(text)
+	if l.Trunc <= 0 {
+		return text, nil
+	}
+
+	var bounds []int
+	if l.Unit == TruncGraphemes || l.Unit == TruncWidth {
+		bounds = graphemes(text)
+	}
+
+	// Offsets and lengths of the units of the text.
+	var offsets, lengths []int
+	var total int
+
+	for end := 0; end < len(text); {
+		_, n := utf8.DecodeRune(text[end:])
+		size, length := l.unit(text, end, n, bounds)
+		offsets = append(offsets, end)
+		lengths = append(lengths, length)
+		total += length
+		end += size
+	}
+
+	if total <= l.Trunc {
+		return text, nil
+	}
+
+	// Head gets the bigger half of the odd length.
+	head, tail := 0, len(offsets)
+	var length int
+
+	for ; head < len(offsets) && length+lengths[head] <= (l.Trunc+1)/2; head++ {
+		length += lengths[head]
+	}
+
+	length = 0
+
+	for ; tail > head && length+lengths[tail-1] <= l.Trunc/2; tail-- {
+		length += lengths[tail-1]
+	}
+
+	headEnd, tailStart := len(text), len(text)
+	if head < len(offsets) {
+		headEnd = offsets[head]
+	}
+	if tail < len(offsets) {
+		tailStart = offsets[tail]
+	}
+
+	mark := l.Marks[Trunc]
+	if len(mark) == 0 {
+		mark = []byte("…")
+	}
+
+	excerpt := make([]byte, 0, headEnd+len(mark)+len(text)-tailStart)
+	excerpt = append(excerpt, text[:headEnd]...)
+	excerpt = append(excerpt, mark...)
+	excerpt = append(excerpt, text[tailStart:]...)
+
+	return excerpt, text[headEnd:tailStart]
+}
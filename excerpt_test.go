@@ -0,0 +1,146 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plog_test
+
+import (
+	"bytes"
+	"encoding"
+	"testing"
+
+	"github.com/kinbiko/jsonassert"
+	"github.com/pfmt/pfmt"
+	"github.com/pfmt/plog"
+)
+
+func TestExcerptStrategy(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		strategy int
+		trunc    int
+		unit     int
+		input    string
+		want     string
+	}{
+		{
+			name:     "first non-blank line of the stack trace",
+			line:     line(),
+			strategy: plog.ExcerptFirstLine,
+			input:    "\n\npanic: runtime error\n\ngoroutine 1 [running]:\n\tmain.main()\n",
+			want: `{
+				"message":"\n\npanic: runtime error\n\ngoroutine 1 [running]:\n\tmain.main()\n",
+				"excerpt":"panic: runtime error",
+				"trail":"goroutine 1 [running]:\n\tmain.main()"
+			}`,
+		}, {
+			name:     "first line is truncated",
+			line:     line(),
+			strategy: plog.ExcerptFirstLine,
+			trunc:    5,
+			input:    "Hello, World!\r\nBye!",
+			want: `{
+				"message":"Hello, World!\r\nBye!",
+				"excerpt":"Hello…",
+				"trail":"Bye!"
+			}`,
+		}, {
+			name:     "single line without remainder",
+			line:     line(),
+			strategy: plog.ExcerptFirstLine,
+			input:    "Hello, World!",
+			want: `{
+				"message":"Hello, World!"
+			}`,
+		}, {
+			name:     "first sentence",
+			line:     line(),
+			strategy: plog.ExcerptSentence,
+			input:    "Connection refused. Retrying in 5.5 seconds...",
+			want: `{
+				"message":"Connection refused. Retrying in 5.5 seconds...",
+				"excerpt":"Connection refused.",
+				"trail":"Retrying in 5.5 seconds..."
+			}`,
+		}, {
+			name:     "first sentence ends with the run of the punctuation",
+			line:     line(),
+			strategy: plog.ExcerptSentence,
+			input:    "What?! No way",
+			want: `{
+				"message":"What?! No way",
+				"excerpt":"What?!",
+				"trail":"No way"
+			}`,
+		}, {
+			name:     "first sentence ends with the blank line",
+			line:     line(),
+			strategy: plog.ExcerptSentence,
+			input:    "Something failed\n\nstack trace",
+			want: `{
+				"message":"Something failed\n\nstack trace",
+				"excerpt":"Something failed",
+				"trail":"stack trace"
+			}`,
+		}, {
+			name:     "head and tail",
+			line:     line(),
+			strategy: plog.ExcerptHeadTail,
+			trunc:    7,
+			input:    "GET /api/v1/users/42",
+			want: `{
+				"message":"GET /api/v1/users/42",
+				"excerpt":"GET …/42",
+				"trail":"/api/v1/users"
+			}`,
+		}, {
+			name:     "head and tail of the runes",
+			line:     line(),
+			strategy: plog.ExcerptHeadTail,
+			trunc:    4,
+			unit:     plog.TruncRunes,
+			input:    "Привет, Мир!",
+			want: `{
+				"message":"Привет, Мир!",
+				"excerpt":"Пр…р!",
+				"trail":"ивет, Ми"
+			}`,
+		}, {
+			name:     "head and tail of the short message",
+			line:     line(),
+			strategy: plog.ExcerptHeadTail,
+			trunc:    20,
+			input:    "Hello, World!",
+			want: `{
+				"message":"Hello, World!"
+			}`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.line+"/"+tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+
+			l := &plog.Log{
+				Output:   &buf,
+				Keys:     [4]encoding.TextMarshaler{pfmt.String("message"), pfmt.String("excerpt"), pfmt.String("trail")},
+				Trunc:    tt.trunc,
+				Unit:     tt.unit,
+				Marks:    [3][]byte{[]byte("…")},
+				Strategy: tt.strategy,
+			}
+
+			_, err := l.Write([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("unwant write error: %s", err)
+			}
+
+			ja := jsonassert.New(testprinter{t: t, link: tt.line})
+			ja.Assertf(buf.String(), tt.want)
+		})
+	}
+}
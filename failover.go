@@ -0,0 +1,407 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plog
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Failover is a writer which writes record to the first available output
+// and spools records to the disk if all outputs fail.
+// Spooled records are replayed in order once any output recovers.
+type Failover struct {
+	Outputs []io.Writer   // Outputs is a destinations for output, primary first.
+	Spool   *Spool        // Spool is an on-disk queue of the records, records are lost if nil.
+	Retry   time.Duration // Retry is a minimum interval between replays of the spooled records, 1 second if zero, on each write if negative.
+
+	mu   sync.Mutex
+	last time.Time
+}
+
+// Write implements io.Writer.
+func (f *Failover) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Spool != nil && f.Spool.Pending() {
+		retry := f.Retry
+		if retry == 0 {
+			retry = time.Second
+		}
+
+		if time.Since(f.last) >= retry {
+			f.last = time.Now()
+			_ = f.Spool.Replay(writerFunc(f.write))
+		}
+
+		// Keeps order: the record waits in the spool
+		// while older records are not replayed.
+		if f.Spool.Pending() {
+			return f.Spool.Write(p)
+		}
+	}
+
+	n, err := f.write(p)
+	if err == nil {
+		return n, nil
+	}
+
+	if f.Spool == nil {
+		return n, err
+	}
+
+	f.last = time.Now()
+	return f.Spool.Write(p)
+}
+
+// Flush replays the spooled records and flushes the outputs.
+func (f *Failover) Flush(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Spool != nil && f.Spool.Pending() {
+		f.last = time.Now()
+		err := f.Spool.Replay(writerFunc(f.write))
+		if err != nil {
+			return err
+		}
+	}
+
+	var first error
+	for _, out := range f.Outputs {
+		err := flush(ctx, out)
+		if err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// write writes record to the first output which succeeds.
+func (f *Failover) write(p []byte) (int, error) {
+	err := errors.New("plog: failover without outputs")
+	for _, out := range f.Outputs {
+		var n int
+		n, err = out.Write(p)
+		if err == nil {
+			return n, nil
+		}
+	}
+	return 0, err
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
+
+// Spool is a bounded on-disk queue of the records stored in the segment files.
+// Each record is prefixed by its length and CRC-32 checksum,
+// corrupt records are skipped with the rest of the segment.
+// Replay is at-least-once: records of the partially replayed segment
+// may be replayed again after restart.
+type Spool struct {
+	Dir         string      // Dir is a directory of the segment files.
+	SegmentSize int64       // SegmentSize is a maximum size of the segment file, 1 MiB if zero.
+	MaxSize     int64       // MaxSize is a maximum total size of the segments, oldest segments are removed if exceeded, 64 MiB if zero.
+	Perm        os.FileMode // Perm is a segment files permissions, 0644 if zero, directory permissions add search to the read permissions, for example 0700 of the 0600.
+
+	mu        sync.Mutex
+	loaded    bool
+	segs      []uint64 // segs is a sequence numbers of the segments, oldest first.
+	sizes     map[uint64]int64
+	w         *os.File
+	offset    int64 // offset is a read offset in the oldest segment.
+	corrupted uint64
+	dropped   uint64
+}
+
+const spoolExt = ".spool"
+
+// perm returns permissions of the segment files.
+func (s *Spool) perm() os.FileMode {
+	if s.Perm == 0 {
+		return 0o644
+	}
+	return s.Perm
+}
+
+func (s *Spool) load() error {
+	if s.loaded {
+		return nil
+	}
+
+	// Directory is searchable by those who may read the segments.
+	perm := s.perm()
+	err := os.MkdirAll(s.Dir, perm|(perm&0o444)>>2)
+	if err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(s.Dir)
+	if err != nil {
+		return err
+	}
+
+	s.sizes = make(map[uint64]int64)
+
+	for _, e := range entries {
+		if !strings.HasSuffix(e.Name(), spoolExt) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(e.Name(), spoolExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			return err
+		}
+		s.segs = append(s.segs, seq)
+		s.sizes[seq] = info.Size()
+	}
+
+	sort.Slice(s.segs, func(i, j int) bool { return s.segs[i] < s.segs[j] })
+
+	s.loaded = true
+	return nil
+}
+
+func (s *Spool) path(seq uint64) string {
+	return filepath.Join(s.Dir, fmt.Sprintf("%020d%s", seq, spoolExt))
+}
+
+// Pending reports whether spool has records.
+func (s *Spool) Pending() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.load()
+	if err != nil {
+		return false
+	}
+
+	return len(s.segs) != 0
+}
+
+// Write implements io.Writer. Appends record to the newest segment.
+func (s *Spool) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.load()
+	if err != nil {
+		return 0, err
+	}
+
+	segSize := s.SegmentSize
+	if segSize <= 0 {
+		segSize = 1 << 20
+	}
+
+	if len(s.segs) == 0 || s.sizes[s.segs[len(s.segs)-1]] >= segSize {
+		err = s.next()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if s.w == nil {
+		seq := s.segs[len(s.segs)-1]
+		s.w, err = os.OpenFile(s.path(seq), os.O_CREATE|os.O_WRONLY|os.O_APPEND, s.perm())
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	rec := make([]byte, 8+len(p))
+	binary.LittleEndian.PutUint32(rec[0:4], uint32(len(p)))
+	binary.LittleEndian.PutUint32(rec[4:8], crc32.ChecksumIEEE(p))
+	copy(rec[8:], p)
+
+	n, err := s.w.Write(rec)
+	s.sizes[s.segs[len(s.segs)-1]] += int64(n)
+	if err != nil {
+		return 0, err
+	}
+
+	s.trim()
+
+	return len(p), nil
+}
+
+// next starts the new segment.
+func (s *Spool) next() error {
+	if s.w != nil {
+		err := s.w.Close()
+		s.w = nil
+		if err != nil {
+			return err
+		}
+	}
+
+	var seq uint64
+	if len(s.segs) != 0 {
+		seq = s.segs[len(s.segs)-1] + 1
+	}
+
+	s.segs = append(s.segs, seq)
+	s.sizes[seq] = 0
+	return nil
+}
+
+// trim removes the oldest segments while total size exceeds maximum size.
+func (s *Spool) trim() {
+	maxSize := s.MaxSize
+	if maxSize <= 0 {
+		maxSize = 64 << 20
+	}
+
+	var total int64
+	for _, seq := range s.segs {
+		total += s.sizes[seq]
+	}
+
+	for total > maxSize && len(s.segs) > 1 {
+		seq := s.segs[0]
+		total -= s.sizes[seq]
+		_ = os.Remove(s.path(seq))
+		delete(s.sizes, seq)
+		s.segs = s.segs[1:]
+		s.offset = 0
+		s.dropped++
+	}
+}
+
+// Replay writes spooled records in order to the writer
+// until the writer fails or spool is empty.
+// Fully replayed segments are removed.
+func (s *Spool) Replay(w io.Writer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.load()
+	if err != nil {
+		return err
+	}
+
+	for len(s.segs) != 0 {
+		seq := s.segs[0]
+
+		err := s.replay(w, seq)
+		if err != nil {
+			return err
+		}
+
+		if len(s.segs) == 1 && s.w != nil {
+			err = s.w.Close()
+			s.w = nil
+			if err != nil {
+				return err
+			}
+		}
+
+		_ = os.Remove(s.path(seq))
+		delete(s.sizes, seq)
+		s.segs = s.segs[1:]
+		s.offset = 0
+	}
+
+	return nil
+}
+
+// replay writes records of the segment starting at the read offset.
+func (s *Spool) replay(w io.Writer, seq uint64) error {
+	f, err := os.Open(s.path(seq))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Seek(s.offset, io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	var head [8]byte
+
+	for {
+		_, err := io.ReadFull(f, head[:])
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			// Truncated record.
+			s.corrupted++
+			s.offset = s.sizes[seq]
+			return nil
+		}
+
+		size := binary.LittleEndian.Uint32(head[0:4])
+		if int64(size) > s.sizes[seq]-s.offset-8 {
+			s.corrupted++
+			s.offset = s.sizes[seq]
+			return nil
+		}
+
+		p := make([]byte, size)
+		_, err = io.ReadFull(f, p)
+		if err != nil || crc32.ChecksumIEEE(p) != binary.LittleEndian.Uint32(head[4:8]) {
+			// Length of the corrupt record is not reliable,
+			// so the rest of the segment is skipped.
+			s.corrupted++
+			s.offset = s.sizes[seq]
+			return nil
+		}
+
+		_, err = w.Write(p)
+		if err != nil {
+			return err
+		}
+
+		s.offset += 8 + int64(size)
+	}
+}
+
+// Corrupted returns number of the skipped corrupt records.
+func (s *Spool) Corrupted() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.corrupted
+}
+
+// Dropped returns number of the segments removed because spool exceeds maximum size.
+func (s *Spool) Dropped() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dropped
+}
+
+// Close closes the segment file.
+func (s *Spool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.w == nil {
+		return nil
+	}
+	err := s.w.Close()
+	s.w = nil
+	return err
+}
@@ -0,0 +1,170 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plog_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pfmt/plog"
+)
+
+// switchWriter fails while it is down.
+type switchWriter struct {
+	down bool
+	buf  bytes.Buffer
+}
+
+func (w *switchWriter) Write(p []byte) (int, error) {
+	if w.down {
+		return 0, errors.New("down")
+	}
+	return w.buf.Write(p)
+}
+
+func TestFailover(t *testing.T) {
+	primary := &switchWriter{}
+	secondary := &switchWriter{down: true}
+
+	f := &plog.Failover{
+		Outputs: []io.Writer{primary, secondary},
+		Spool:   &plog.Spool{Dir: t.TempDir(), SegmentSize: 16},
+		Retry:   -1,
+	}
+	defer f.Spool.Close()
+
+	write := func(s string) {
+		t.Helper()
+		_, err := f.Write([]byte(s))
+		if err != nil {
+			t.Fatalf("unwant write error: %s", err)
+		}
+	}
+
+	write("1\n")
+
+	primary.down = true
+	secondary.down = false
+	write("2\n")
+
+	secondary.down = true
+	write("3\n")
+	write("4\n")
+	write("5\n")
+
+	if !f.Spool.Pending() {
+		t.Fatal("want spooled records")
+	}
+
+	primary.down = false
+	write("6\n")
+
+	if f.Spool.Pending() {
+		t.Error("unwant spooled records after recovery")
+	}
+
+	if primary.buf.String() != "1\n3\n4\n5\n6\n" {
+		t.Errorf("want records in order, got: %q", primary.buf.String())
+	}
+	if secondary.buf.String() != "2\n" {
+		t.Errorf("want failover record, got: %q", secondary.buf.String())
+	}
+}
+
+func TestSpoolPerm(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "spool")
+
+	s := &plog.Spool{Dir: dir, Perm: 0o600}
+	_, err := s.Write([]byte("Hello, World!\n"))
+	if err != nil {
+		t.Fatalf("unwant spool error: %s", err)
+	}
+	err = s.Close()
+	if err != nil {
+		t.Fatalf("unwant close error: %s", err)
+	}
+
+	fi, err := os.Stat(dir)
+	if err != nil {
+		t.Fatalf("unwant stat error: %s", err)
+	}
+	if fi.Mode().Perm() != 0o700 {
+		t.Errorf("want spool directory permissions 0700, got: %o", fi.Mode().Perm())
+	}
+
+	segs, err := filepath.Glob(filepath.Join(dir, "*.spool"))
+	if err != nil || len(segs) != 1 {
+		t.Fatalf("want 1 segment, got: %q, %v", segs, err)
+	}
+
+	fi, err = os.Stat(segs[0])
+	if err != nil {
+		t.Fatalf("unwant stat error: %s", err)
+	}
+	if fi.Mode().Perm() != 0o600 {
+		t.Errorf("want segment permissions 0600, got: %o", fi.Mode().Perm())
+	}
+}
+
+func TestSpoolCorrupt(t *testing.T) {
+	dir := t.TempDir()
+
+	s := &plog.Spool{Dir: dir, SegmentSize: 16}
+	for _, r := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		_, err := s.Write([]byte(r))
+		if err != nil {
+			t.Fatalf("unwant spool error: %s", err)
+		}
+	}
+	err := s.Close()
+	if err != nil {
+		t.Fatalf("unwant close error: %s", err)
+	}
+
+	segs, err := filepath.Glob(filepath.Join(dir, "*.spool"))
+	if err != nil || len(segs) != 2 {
+		t.Fatalf("want 2 segments, got: %q, %v", segs, err)
+	}
+
+	// Corrupts payload of the first record of the first segment.
+	p, err := os.ReadFile(segs[0])
+	if err != nil {
+		t.Fatalf("unwant read error: %s", err)
+	}
+	p[9] ^= 0xff
+	err = os.WriteFile(segs[0], p, 0o644)
+	if err != nil {
+		t.Fatalf("unwant write error: %s", err)
+	}
+
+	// Reopens the spool, as after restart.
+	s = &plog.Spool{Dir: dir}
+
+	var buf bytes.Buffer
+	err = s.Replay(&buf)
+	if err != nil {
+		t.Fatalf("unwant replay error: %s", err)
+	}
+
+	if buf.String() != "third\nfourth\n" {
+		t.Errorf("want records of the intact segment, got: %q", buf.String())
+	}
+	if s.Corrupted() != 1 {
+		t.Errorf("want 1 corrupted record, got: %d", s.Corrupted())
+	}
+	if s.Pending() {
+		t.Error("unwant spooled records after replay")
+	}
+
+	err = (&plog.Failover{Spool: s}).Flush(context.Background())
+	if err != nil {
+		t.Errorf("unwant flush error: %s", err)
+	}
+}
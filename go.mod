@@ -1,14 +1,23 @@
 module github.com/pfmt/plog
 
-go 1.17
+go 1.21
 
 require (
 	github.com/json-iterator/go v1.1.12
 	github.com/kinbiko/jsonassert v1.0.2
 	github.com/pfmt/pfmt v0.3.0
+	github.com/rivo/uniseg v0.2.0
+	go.opentelemetry.io/otel v1.28.0
+	go.opentelemetry.io/otel/sdk v1.28.0
+	go.opentelemetry.io/otel/trace v1.28.0
+	golang.org/x/sys v0.21.0
 )
 
 require (
+	github.com/go-logr/logr v1.4.2 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/google/uuid v1.6.0 // indirect
 	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
 	github.com/modern-go/reflect2 v1.0.2 // indirect
+	go.opentelemetry.io/otel/metric v1.28.0 // indirect
 )
@@ -0,0 +1,159 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plog
+
+import (
+	"encoding"
+	"encoding/json"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/pfmt/pfmt"
+)
+
+// kvg is a key and a group of the key-values implements json/text marshaler.
+// Group is encoded as a nested JSON object, groups of the same key are merged
+// and later key-values of the same key have a higher priority.
+type kvg struct {
+	K  encoding.TextMarshaler
+	KV []pfmt.KV
+}
+
+func (kv kvg) MarshalText() (text []byte, err error) { return kv.K.MarshalText() }
+
+func (kv kvg) MarshalJSON() ([]byte, error) {
+	m, err := kv.object()
+	if err != nil {
+		return nil, err
+	}
+	return m.MarshalJSON()
+}
+
+// object returns key-values of the group.
+func (kv kvg) object() (object, error) {
+	m := make(object, len(kv.KV))
+	for _, x := range kv.KV {
+		p, err := x.MarshalText()
+		if err != nil {
+			return nil, err
+		}
+		put(m, string(p), x)
+	}
+	return m, nil
+}
+
+func StringGroup(k string, kv ...pfmt.KV) kvg {
+	return kvg{K: pfmt.String(k), KV: kv}
+}
+
+func TextGroup(k encoding.TextMarshaler, kv ...pfmt.KV) kvg {
+	return kvg{K: k, KV: kv}
+}
+
+// object is a JSON object of the key-values.
+type object map[string]json.Marshaler
+
+func (m object) MarshalJSON() ([]byte, error) {
+	return jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(map[string]json.Marshaler(m))
+}
+
+// put puts the value of the key into the map
+// merging groups of the same key.
+func put(dst map[string]json.Marshaler, k string, v json.Marshaler) {
+	if e, ok := v.(kve); ok && e.V != nil {
+		v = e.group(false)
+	}
+
+	if g, ok := v.(kvg); ok {
+		if old, ok := dst[k].(kvg); ok {
+			g.KV = append(append(make([]pfmt.KV, 0, len(old.KV)+len(g.KV)), old.KV...), g.KV...)
+		}
+		dst[k] = g
+		return
+	}
+	dst[k] = v
+}
+
+// add adds key-value to the record flattening groups
+// to the dotted keys if Flatten is set.
+func (l *Log) add(dst map[string]json.Marshaler, kv pfmt.KV) error {
+	p, err := kv.MarshalText()
+	if err != nil {
+		return err
+	}
+	return l.addKey(dst, string(p), kv)
+}
+
+func (l *Log) addKey(dst map[string]json.Marshaler, k string, v json.Marshaler) error {
+	if e, ok := v.(kve); ok && e.V != nil && l.Flatten {
+		v = e.group(true)
+	}
+
+	g, ok := v.(kvg)
+	if !ok || !l.Flatten {
+		put(dst, k, v)
+		return nil
+	}
+
+	sep := l.Separator
+	if sep == "" {
+		sep = "."
+	}
+
+	// Prefix is added to the top-level key of the flattened group only.
+	if !strings.HasPrefix(k, l.Prefix) {
+		k = l.Prefix + k
+	}
+
+	for _, x := range g.KV {
+		p, err := x.MarshalText()
+		if err != nil {
+			return err
+		}
+		err = l.addKey(dst, k+sep+string(p), x)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Group returns copy of the logger which puts
+// the additional key-values of the tee'd loggers into the group of the name.
+func (l *Log) Group(name string) Logger {
+	l0 := l.Tee().(*Log)
+	l0.Groups = append(l0.Groups, name)
+	return l0
+}
+
+// group puts key-values into the nested groups of the logger.
+// Severity levels stay at the top level, so the level of the record
+// is available for the stack traces, the samplers and the outputs.
+func (l *Log) group(kv []pfmt.KV) []pfmt.KV {
+	if len(l.Groups) == 0 || len(kv) == 0 {
+		return kv
+	}
+
+	var top, rest []pfmt.KV
+	for _, x := range kv {
+		if _, ok := x.(Leveler); ok {
+			top = append(top, x)
+		} else {
+			rest = append(rest, x)
+		}
+	}
+
+	if len(rest) == 0 {
+		return top
+	}
+
+	g := StringGroup(l.Groups[len(l.Groups)-1], rest...)
+	for i := len(l.Groups) - 2; i >= 0; i-- {
+		g = StringGroup(l.Groups[i], g)
+	}
+
+	return append(top, g)
+}
@@ -0,0 +1,128 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plog_test
+
+import (
+	"bytes"
+	"encoding"
+	"testing"
+
+	"github.com/kinbiko/jsonassert"
+	"github.com/pfmt/pfmt"
+	"github.com/pfmt/plog"
+)
+
+func TestGroup(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		flatten bool
+		log     func(l *plog.Log) plog.Logger
+		want    string
+	}{
+		{
+			name: "group",
+			line: line(),
+			log: func(l *plog.Log) plog.Logger {
+				return l.Tee(plog.StringGroup("http", plog.StringString("method", "GET"), plog.StringInt("status", 200)))
+			},
+			want: `{
+				"message":"Hello, World!",
+				"http":{"method":"GET","status":200}
+			}`,
+		}, {
+			name: "groups of the same key are merged and later values win",
+			line: line(),
+			log: func(l *plog.Log) plog.Logger {
+				return l.Tee(
+					plog.StringGroup("http", plog.StringString("method", "GET"), plog.StringInt("status", 200)),
+				).Tee(
+					plog.StringGroup("http", plog.StringInt("status", 404), plog.StringInt("status", 500)),
+				)
+			},
+			want: `{
+				"message":"Hello, World!",
+				"http":{"method":"GET","status":500}
+			}`,
+		}, {
+			name: "namespace of the tee'd logger",
+			line: line(),
+			log: func(l *plog.Log) plog.Logger {
+				return l.Tee(plog.StringString("service", "api")).(*plog.Log).
+					Group("http").
+					Tee(plog.StringString("method", "GET")).(*plog.Log).
+					Group("response").
+					Tee(plog.StringInt("status", 200))
+			},
+			want: `{
+				"message":"Hello, World!",
+				"service":"api",
+				"http":{"method":"GET","response":{"status":200}}
+			}`,
+		}, {
+			name:    "flatten groups to the dotted keys",
+			line:    line(),
+			flatten: true,
+			log: func(l *plog.Log) plog.Logger {
+				return l.Group("http").Tee(
+					plog.StringString("method", "GET"),
+					plog.StringGroup("response", plog.StringInt("status", 200)),
+				).Tee(plog.StringString("method", "POST"))
+			},
+			want: `{
+				"message":"Hello, World!",
+				"http.method":"POST",
+				"http.response.status":200
+			}`,
+		}, {
+			name: "severity level stays at the top level of the group",
+			line: line(),
+			log: func(l *plog.Log) plog.Logger {
+				l.Stack = &plog.Stack{Level: "err", Text: true}
+				return l.Group("http").Tee(plog.StringLevel("level", "error"), plog.StringString("method", "GET"))
+			},
+			want: `{
+				"message":"Hello, World!",
+				"level":"error",
+				"http":{"method":"GET"},
+				"stack":"<<PRESENCE>>"
+			}`,
+		}, {
+			name: "redact keys inside group",
+			line: line(),
+			log: func(l *plog.Log) plog.Logger {
+				l.Redactor = &plog.Redact{Keys: []string{"*password*"}}
+				return l.Tee(plog.StringGroup("user", plog.StringString("name", "alice"), plog.StringString("password", "secret")))
+			},
+			want: `{
+				"message":"Hello, World!",
+				"user":{"name":"alice","password":"[REDACTED]"}
+			}`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.line+"/"+tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+
+			l := &plog.Log{
+				Output:  &buf,
+				Keys:    [4]encoding.TextMarshaler{pfmt.String("message")},
+				Flatten: tt.flatten,
+			}
+
+			_, err := tt.log(l).Write([]byte("Hello, World!"))
+			if err != nil {
+				t.Fatalf("unwant write error: %s", err)
+			}
+
+			ja := jsonassert.New(testprinter{t: t, link: tt.line})
+			ja.Assertf(buf.String(), tt.want)
+		})
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plog
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrForged is returned by the verifier if line has no HMAC field.
+	ErrForged = errors.New("plog: record without HMAC")
+	// ErrTampered is returned by the verifier if HMAC of the line does not match.
+	ErrTampered = errors.New("plog: record HMAC mismatch")
+)
+
+// harden compacts the JSON record, so it is exactly one line,
+// and escapes CR/LF in all string values, so the decoded values
+// do not contain line breaks.
+// Returns error if record is not a valid JSON.
+func harden(p []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	err := json.Compact(&buf, p)
+	if err != nil {
+		return nil, err
+	}
+
+	p = buf.Bytes()
+	out := make([]byte, 0, len(p))
+
+	var str bool
+
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+
+		if !str {
+			str = c == '"'
+			out = append(out, c)
+			continue
+		}
+
+		switch c {
+		case '"':
+			str = false
+			out = append(out, c)
+
+		case '\\':
+			esc := p[i+1]
+
+			switch {
+			case esc == 'n', esc == 'r':
+				out = append(out, '\\', '\\', esc)
+				i++
+
+			case esc == 'u':
+				switch code := strings.ToLower(string(p[i+2 : i+6])); code {
+				case "000a":
+					out = append(out, `\\n`...)
+				case "000d":
+					out = append(out, `\\r`...)
+				case "2028", "2029":
+					out = append(out, `\\u`+code...)
+				default:
+					out = append(out, p[i:i+6]...)
+				}
+				i += 5
+
+			default:
+				out = append(out, c, esc)
+				i++
+			}
+
+		case 0xE2:
+			// Raw U+2028 LINE SEPARATOR and U+2029 PARAGRAPH SEPARATOR.
+			if i+2 < len(p) && p[i+1] == 0x80 && (p[i+2] == 0xA8 || p[i+2] == 0xA9) {
+				out = append(out, `\\u202`...)
+				out = append(out, "89"[p[i+2]-0xA8])
+				i += 2
+				continue
+			}
+			out = append(out, c)
+
+		default:
+			out = append(out, c)
+		}
+	}
+
+	return out, nil
+}
+
+// sign appends HMAC-SHA256 of the JSON record to the record.
+func (l *Log) sign(p []byte) []byte {
+	mac := hmac.New(sha256.New, l.HMAC)
+	mac.Write(p)
+	sum := hex.EncodeToString(mac.Sum(nil))
+
+	key := l.HMACKey
+	if key == "" {
+		key = "hmac"
+	}
+
+	return appendField(p, strconv.Quote(key)+":"+strconv.Quote(sum))
+}
+
+// Verifier verifies per-record HMAC of the JSON lines.
+type Verifier struct {
+	Secret []byte // Secret is a key of the HMAC.
+	Key    string // Key is a key of the HMAC field, "hmac" if empty.
+}
+
+// Verify returns ErrForged if line has no HMAC field
+// and ErrTampered if HMAC does not match the line.
+func (v Verifier) Verify(line []byte) error {
+	key := v.Key
+	if key == "" {
+		key = "hmac"
+	}
+
+	line = bytes.TrimRight(line, "\r\n")
+
+	field := []byte(strconv.Quote(key) + `:"`)
+	// Field is the last one: "key":"<64 hex digits>"}.
+	i := len(line) - len(field) - sha256.Size*2 - 2
+	if i < 1 || !bytes.Equal(line[i:i+len(field)], field) || !bytes.HasSuffix(line, []byte(`"}`)) {
+		return ErrForged
+	}
+
+	sum, err := hex.DecodeString(string(line[i+len(field) : len(line)-2]))
+	if err != nil {
+		return ErrForged
+	}
+
+	rec := append([]byte(nil), line[:i]...)
+	if rec[len(rec)-1] == ',' {
+		rec = rec[:len(rec)-1]
+	}
+	rec = append(rec, '}')
+
+	mac := hmac.New(sha256.New, v.Secret)
+	mac.Write(rec)
+	if !hmac.Equal(sum, mac.Sum(nil)) {
+		return ErrTampered
+	}
+
+	return nil
+}
+
+// VerifyLines verifies lines of the reader and returns number of the first
+// forged or tampered line starting from 1 and error of the verification.
+func (v Verifier) VerifyLines(r io.Reader) (int, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<26)
+
+	var n int
+
+	for sc.Scan() {
+		n++
+
+		err := v.Verify(sc.Bytes())
+		if err != nil {
+			return n, err
+		}
+	}
+
+	return 0, sc.Err()
+}
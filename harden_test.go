@@ -0,0 +1,89 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plog_test
+
+import (
+	"bytes"
+	"encoding"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kinbiko/jsonassert"
+	"github.com/pfmt/pfmt"
+	"github.com/pfmt/plog"
+)
+
+func TestHarden(t *testing.T) {
+	var buf bytes.Buffer
+
+	l := &plog.Log{
+		Output: &buf,
+		Keys:   [4]encoding.TextMarshaler{pfmt.String("message")},
+		KV: []pfmt.KV{
+			plog.StringString("user", "alice\n{\"level\":\"info\",\"user\":\"admin\"}"),
+			plog.StringStrings("tags", []string{"a\r\nb", "c\u2028d"}),
+		},
+		Harden: true,
+	}
+
+	_, err := l.Write([]byte("login\n{\"message\":\"forged\"}\\"))
+	if err != nil {
+		t.Fatalf("unwant write error: %s", err)
+	}
+
+	if n := strings.Count(buf.String(), "\n"); n != 1 {
+		t.Fatalf("want one line, got %d: %q", n, buf.String())
+	}
+
+	ja := jsonassert.New(testprinter{t: t, link: line()})
+	ja.Assertf(buf.String(), `{
+		"message":"login\\n{\"message\":\"forged\"}\\",
+		"user":"alice\\n{\"level\":\"info\",\"user\":\"admin\"}",
+		"tags":["a\\r\\nb","c\\u2028d"]
+	}`)
+}
+
+func TestVerifier(t *testing.T) {
+	secret := []byte("secret")
+
+	var buf bytes.Buffer
+
+	l := &plog.Log{
+		Output: &buf,
+		Keys:   [4]encoding.TextMarshaler{pfmt.String("message")},
+		HMAC:   secret,
+	}
+
+	for _, msg := range []string{"Hello, World!", "Bye!"} {
+		_, err := l.Write([]byte(msg))
+		if err != nil {
+			t.Fatalf("unwant write error: %s", err)
+		}
+	}
+
+	v := plog.Verifier{Secret: secret}
+
+	n, err := v.VerifyLines(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("unwant verify error at line %d: %s", n, err)
+	}
+
+	lines := strings.SplitAfter(buf.String(), "\n")
+
+	tampered := lines[0] + strings.Replace(lines[1], "Bye!", "Hi!!", 1)
+
+	n, err = v.VerifyLines(strings.NewReader(tampered))
+	if !errors.Is(err, plog.ErrTampered) || n != 2 {
+		t.Errorf("want tampered line 2, got line %d: %v", n, err)
+	}
+
+	forged := lines[0] + `{"message":"forged"}` + "\n" + lines[1]
+
+	n, err = v.VerifyLines(strings.NewReader(forged))
+	if !errors.Is(err, plog.ErrForged) || n != 2 {
+		t.Errorf("want forged line 2, got line %d: %v", n, err)
+	}
+}
@@ -0,0 +1,311 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// HEC is a Splunk HTTP Event Collector writer
+// <https://docs.splunk.com/Documentation/Splunk/latest/Data/FormateventsforHTTPEventCollector>.
+// Each JSON record wraps into the HEC envelope as an event
+// and records sends in batches by the single POST request.
+type HEC struct {
+	URL        string        // URL is a collector endpoint, for example https://splunk:8088/services/collector/event.
+	Token      string        // Token is a HEC token.
+	Host       string        // Host is a host field of the envelope.
+	Source     string        // Source is a source field of the envelope.
+	Sourcetype string        // Sourcetype is a sourcetype field of the envelope.
+	Index      string        // Index is an index field of the envelope.
+	Batch      int           // Batch is a maximum number of the records per request, 1 if zero.
+	Size       int           // Size is a maximum number of the buffered records, the oldest records are dropped if exceeded, 10000 if zero.
+	Gzip       bool          // Gzip compresses request body.
+	Channel    string        // Channel is a X-Splunk-Request-Channel, random if empty and acknowledgement is on.
+	Ack        bool          // Ack waits for indexer acknowledgement of the each request.
+	AckTimeout time.Duration // AckTimeout is a maximum time of the waiting for indexer acknowledgement, 1 minute if zero.
+	Client     *http.Client  // Client is a HTTP client, http.DefaultClient if nil.
+
+	mu      sync.Mutex
+	buf     [][]byte
+	dropped uint64
+	err     error
+}
+
+// hecEvent is a HEC envelope.
+type hecEvent struct {
+	Time       float64         `json:"time"`
+	Host       string          `json:"host,omitempty"`
+	Source     string          `json:"source,omitempty"`
+	Sourcetype string          `json:"sourcetype,omitempty"`
+	Index      string          `json:"index,omitempty"`
+	Event      json.RawMessage `json:"event"`
+}
+
+// Write implements io.Writer. Appends the record to the batch
+// and sends the batch if it is full. Record is written once it is buffered,
+// error of the send is returned by the next flush and the failed batch
+// is sent again.
+func (h *HEC) Write(p []byte) (int, error) {
+	e := hecEvent{
+		Time:       float64(time.Now().UnixNano()/int64(time.Millisecond)) / 1000,
+		Host:       h.Host,
+		Source:     h.Source,
+		Sourcetype: h.Sourcetype,
+		Index:      h.Index,
+		Event:      bytes.TrimRight(p, "\n"),
+	}
+
+	b, err := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(e)
+	if err != nil {
+		return 0, err
+	}
+
+	h.mu.Lock()
+	h.buf = append(h.buf, b)
+	h.trim()
+	full := len(h.buf) >= h.Batch
+	h.mu.Unlock()
+
+	if full {
+		err = h.flush(context.Background())
+		if err != nil {
+			h.mu.Lock()
+			h.err = err
+			h.mu.Unlock()
+		}
+	}
+
+	return len(p), nil
+}
+
+// Flush sends the batched records and returns error of the send
+// or error of the last failed send of the full batch by the write.
+func (h *HEC) Flush(ctx context.Context) error {
+	err := h.flush(ctx)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err == nil {
+		err = h.err
+	}
+	h.err = nil
+
+	return err
+}
+
+// Dropped returns number of the records dropped because buffer is full.
+func (h *HEC) Dropped() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.dropped
+}
+
+// flush sends the batched records.
+// Batch is sent without holding the lock, so writes are not blocked
+// by the request and the acknowledgement polling.
+// Failed batch is returned to the head of the buffer and sent by the next flush.
+func (h *HEC) flush(ctx context.Context) error {
+	records, channel, err := h.take()
+	if err != nil || len(records) == 0 {
+		return err
+	}
+
+	err = h.send(ctx, bytes.Join(records, nil), channel)
+	if err != nil {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.buf = append(records, h.buf...)
+		h.trim()
+		return err
+	}
+
+	return nil
+}
+
+// trim drops the oldest records if the buffer exceeds the maximum size.
+func (h *HEC) trim() {
+	size := h.Size
+	if size == 0 {
+		size = 10000
+	}
+
+	n := len(h.buf) - size
+	if n > 0 {
+		h.buf = append(h.buf[:0], h.buf[n:]...)
+		h.dropped += uint64(n)
+	}
+}
+
+// Close sends the batched records.
+func (h *HEC) Close() error {
+	return h.Flush(context.Background())
+}
+
+// take takes the batched records and the channel out of the writer.
+func (h *HEC) take() ([][]byte, string, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.buf) == 0 {
+		return nil, "", nil
+	}
+
+	if h.Ack && h.Channel == "" {
+		var u [16]byte
+		_, err := rand.Read(u[:])
+		if err != nil {
+			return nil, "", err
+		}
+		u[6] = u[6]&0x0f | 0x40
+		u[8] = u[8]&0x3f | 0x80
+		h.Channel = fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:])
+	}
+
+	records := h.buf
+	h.buf = nil
+
+	return records, h.Channel, nil
+}
+
+// send sends the batch and waits for the acknowledgement if it is on.
+func (h *HEC) send(ctx context.Context, batch []byte, channel string) error {
+	body := batch
+
+	if h.Gzip {
+		var z bytes.Buffer
+		w := gzip.NewWriter(&z)
+		_, err := w.Write(body)
+		if err != nil {
+			return err
+		}
+		err = w.Close()
+		if err != nil {
+			return err
+		}
+		body = z.Bytes()
+	}
+
+	var res struct {
+		Text  string `json:"text"`
+		Code  int    `json:"code"`
+		AckID *int64 `json:"ackId"`
+	}
+
+	err := h.post(ctx, h.URL, channel, body, h.Gzip, &res)
+	if err != nil {
+		return err
+	}
+
+	if !h.Ack || res.AckID == nil {
+		return nil
+	}
+
+	return h.ack(ctx, channel, *res.AckID)
+}
+
+// ack polls the acknowledgement endpoint until the request is indexed.
+func (h *HEC) ack(ctx context.Context, channel string, id int64) error {
+	timeout := h.AckTimeout
+	if timeout == 0 {
+		timeout = time.Minute
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	url := h.URL
+	if i := strings.Index(url, "/services/collector"); i != -1 {
+		url = url[:i]
+	}
+	url += "/services/collector/ack?channel=" + channel
+
+	body := []byte(`{"acks":[` + strconv.FormatInt(id, 10) + `]}`)
+
+	for delay := 10 * time.Millisecond; ; delay *= 2 {
+		var res struct {
+			Acks map[string]bool `json:"acks"`
+		}
+
+		err := h.post(ctx, url, channel, body, false, &res)
+		if err != nil {
+			return err
+		}
+
+		if res.Acks[strconv.FormatInt(id, 10)] {
+			return nil
+		}
+
+		if delay > time.Second {
+			delay = time.Second
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("hec ack %d: %w", id, ctx.Err())
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (h *HEC) post(ctx context.Context, url, channel string, body []byte, gz bool, res interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Splunk "+h.Token)
+	req.Header.Set("Content-Type", "application/json")
+	if gz {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	if channel != "" {
+		req.Header.Set("X-Splunk-Request-Channel", channel)
+	}
+
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	p, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("hec: %s: %s", resp.Status, bytes.TrimSpace(p))
+	}
+
+	if len(p) == 0 {
+		return nil
+	}
+
+	err = jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(p, res)
+	if err != nil {
+		return errors.New("hec: malformed response: " + string(p))
+	}
+
+	return nil
+}
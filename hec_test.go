@@ -0,0 +1,262 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plog_test
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/kinbiko/jsonassert"
+	"github.com/pfmt/pfmt"
+	"github.com/pfmt/plog"
+)
+
+type hecStub struct {
+	mu      sync.Mutex
+	events  []string
+	acks    int
+	headers http.Header
+}
+
+func (s *hecStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Splunk secret" {
+		http.Error(w, `{"text":"Invalid token","code":4}`, http.StatusForbidden)
+		return
+	}
+
+	if r.URL.Path == "/services/collector/ack" {
+		s.acks++
+		if s.acks < 2 {
+			io.WriteString(w, `{"acks":{"7":false}}`)
+			return
+		}
+		io.WriteString(w, `{"acks":{"7":true}}`)
+		return
+	}
+
+	s.headers = r.Header.Clone()
+
+	var body io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		z, err := gzip.NewReader(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		body = z
+	}
+
+	dec := json.NewDecoder(bufio.NewReader(body))
+	for dec.More() {
+		var e json.RawMessage
+		err := dec.Decode(&e)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.events = append(s.events, string(e))
+	}
+
+	io.WriteString(w, `{"text":"Success","code":0,"ackId":7}`)
+}
+
+func TestHEC(t *testing.T) {
+	stub := &hecStub{}
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	h := &plog.HEC{
+		URL:        srv.URL + "/services/collector/event",
+		Token:      "secret",
+		Host:       "localhost",
+		Sourcetype: "_json",
+		Index:      "main",
+		Batch:      2,
+		Gzip:       true,
+		Ack:        true,
+		Client:     srv.Client(),
+	}
+
+	l := &plog.Log{Output: h, Keys: [4]encoding.TextMarshaler{pfmt.String("message")}}
+
+	for _, msg := range []string{"Hello,", "World!", "Tail"} {
+		_, err := l.Write([]byte(msg))
+		if err != nil {
+			t.Fatalf("unwant write error: %s", err)
+		}
+	}
+
+	stub.mu.Lock()
+	if len(stub.events) != 2 {
+		t.Errorf("want 2 events of the first batch, got: %d", len(stub.events))
+	}
+	if stub.headers.Get("X-Splunk-Request-Channel") == "" {
+		t.Error("want request channel")
+	}
+	if stub.acks != 2 {
+		t.Errorf("want 2 acknowledgement requests, got: %d", stub.acks)
+	}
+	stub.mu.Unlock()
+
+	err := h.Flush(context.Background())
+	if err != nil {
+		t.Fatalf("unwant flush error: %s", err)
+	}
+
+	stub.mu.Lock()
+	defer stub.mu.Unlock()
+
+	if len(stub.events) != 3 {
+		t.Fatalf("want 3 events, got: %d", len(stub.events))
+	}
+
+	var e map[string]json.RawMessage
+	err = json.Unmarshal([]byte(stub.events[0]), &e)
+	if err != nil {
+		t.Fatalf("unwant unmarshal error: %s", err)
+	}
+	if _, ok := e["time"]; !ok {
+		t.Errorf("want time field, got: %s", stub.events[0])
+	}
+	delete(e, "time")
+	p, _ := json.Marshal(e)
+
+	ja := jsonassert.New(testprinter{t: t, link: line()})
+	ja.Assertf(string(p), `{
+		"host":"localhost",
+		"sourcetype":"_json",
+		"index":"main",
+		"event":{"message":"Hello,"}
+	}`)
+}
+
+func TestHECError(t *testing.T) {
+	srv := httptest.NewServer(&hecStub{})
+	defer srv.Close()
+
+	h := &plog.HEC{URL: srv.URL + "/services/collector/event", Token: "wrong", Client: srv.Client()}
+
+	msg := `{"message":"Hello, World!"}` + "\n"
+
+	// Record is written once it is buffered, so the failover does not write it twice.
+	n, err := h.Write([]byte(msg))
+	if err != nil || n != len(msg) {
+		t.Fatalf("want buffered record written, got: %d, %v", n, err)
+	}
+
+	err = h.Flush(context.Background())
+	if err == nil || !bytes.Contains([]byte(err.Error()), []byte("Invalid token")) {
+		t.Errorf("want invalid token error, got: %v", err)
+	}
+}
+
+func TestHECSize(t *testing.T) {
+	stub := &hecStub{}
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	h := &plog.HEC{URL: srv.URL + "/services/collector/event", Token: "secret", Batch: 10, Size: 2, Client: srv.Client()}
+
+	for _, msg := range []string{"Hello,", "World", "!"} {
+		_, err := h.Write([]byte(`{"message":"` + msg + `"}` + "\n"))
+		if err != nil {
+			t.Fatalf("unwant write error: %s", err)
+		}
+	}
+
+	if h.Dropped() != 1 {
+		t.Errorf("want 1 dropped record, got: %d", h.Dropped())
+	}
+
+	err := h.Flush(context.Background())
+	if err != nil {
+		t.Fatalf("unwant flush error: %s", err)
+	}
+
+	stub.mu.Lock()
+	defer stub.mu.Unlock()
+
+	if len(stub.events) != 2 {
+		t.Fatalf("want 2 newest events, got: %q", stub.events)
+	}
+
+	ja := jsonassert.New(testprinter{t: t, link: line()})
+	ja.Assertf(stub.events[0], `{"time":"<<PRESENCE>>","event":{"message":"World"}}`)
+	ja.Assertf(stub.events[1], `{"time":"<<PRESENCE>>","event":{"message":"!"}}`)
+}
+
+func TestHECRetry(t *testing.T) {
+	stub := &hecStub{}
+
+	var mu sync.Mutex
+	fail := true
+	block := make(chan struct{})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		f := fail
+		fail = false
+		mu.Unlock()
+
+		if f {
+			// Holds the first request until the concurrent write is done.
+			<-block
+			http.Error(w, `{"text":"Server is busy","code":9}`, http.StatusServiceUnavailable)
+			return
+		}
+		stub.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	h := &plog.HEC{URL: srv.URL + "/services/collector/event", Token: "secret", Batch: 10, Client: srv.Client()}
+
+	_, err := h.Write([]byte(`{"message":"Hello,"}` + "\n"))
+	if err != nil {
+		t.Fatalf("unwant write error: %s", err)
+	}
+
+	done := make(chan error)
+	go func() { done <- h.Flush(context.Background()) }()
+
+	// Write is not blocked by the pending request of the flush.
+	_, err = h.Write([]byte(`{"message":"World!"}` + "\n"))
+	if err != nil {
+		t.Fatalf("unwant write error: %s", err)
+	}
+	close(block)
+
+	err = <-done
+	if err == nil || !bytes.Contains([]byte(err.Error()), []byte("Server is busy")) {
+		t.Fatalf("want server is busy error, got: %v", err)
+	}
+
+	err = h.Flush(context.Background())
+	if err != nil {
+		t.Fatalf("unwant flush error: %s", err)
+	}
+
+	stub.mu.Lock()
+	defer stub.mu.Unlock()
+
+	if len(stub.events) != 2 {
+		t.Fatalf("want 2 events of the failed and the next batch, got: %q", stub.events)
+	}
+
+	ja := jsonassert.New(testprinter{t: t, link: line()})
+	ja.Assertf(stub.events[0], `{"time":"<<PRESENCE>>","event":{"message":"Hello,"}}`)
+	ja.Assertf(stub.events[1], `{"time":"<<PRESENCE>>","event":{"message":"World!"}}`)
+}
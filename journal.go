@@ -0,0 +1,249 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build linux
+// +build linux
+
+package plog
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	jsoniter "github.com/json-iterator/go"
+	"golang.org/x/sys/unix"
+)
+
+// JournalSocket is a default path of the systemd-journald native protocol socket.
+const JournalSocket = "/run/systemd/journal/socket"
+
+// Journal is a systemd-journald writer, sends each JSON record
+// as a datagram of the native journal protocol
+// <https://systemd.io/JOURNAL_NATIVE_PROTOCOL/>.
+type Journal struct {
+	Addr  string                    // Addr is a path of the journald socket, JournalSocket if empty.
+	Keys  [4]encoding.TextMarshaler // Keys: 0 = original message; 1 = message excerpt; 2 = message trail; 3 = file path.
+	Level encoding.TextMarshaler    // Level is a key of the severity level, "level" if nil.
+
+	mu   sync.Mutex
+	conn *net.UnixConn
+	addr *net.UnixAddr
+}
+
+// Write implements io.Writer. Converts JSON record to the journal fields:
+// PRIORITY from the severity level, MESSAGE from the excerpt or the original message,
+// MESSAGE_FULL from the original message if the excerpt is used,
+// CODE_FILE and CODE_LINE from the file path, all other keys upper-cased.
+func (j *Journal) Write(p []byte) (int, error) {
+	var rec map[string]json.RawMessage
+	err := jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(p, &rec)
+	if err != nil {
+		return 0, err
+	}
+
+	var buf bytes.Buffer
+
+	levelKey := "level"
+	if j.Level != nil {
+		k, err := j.Level.MarshalText()
+		if err != nil {
+			return 0, err
+		}
+		levelKey = string(k)
+	}
+
+	if v, ok := rec[levelKey]; ok {
+		if sev, ok := severity(journalValue(v)); ok {
+			journalAppend(&buf, "PRIORITY", strconv.Itoa(sev))
+			delete(rec, levelKey)
+		}
+	}
+
+	keys := make([]string, len(j.Keys))
+	for i, k := range j.Keys {
+		if k == nil {
+			continue
+		}
+		p, err := k.MarshalText()
+		if err != nil {
+			return 0, err
+		}
+		keys[i] = string(p)
+	}
+
+	for _, i := range []int{Excerpt, Original} {
+		if keys[i] == "" {
+			continue
+		}
+		if v, ok := rec[keys[i]]; ok {
+			journalAppend(&buf, "MESSAGE", journalValue(v))
+			delete(rec, keys[i])
+			break
+		}
+	}
+
+	if keys[File] != "" {
+		if v, ok := rec[keys[File]]; ok {
+			file := journalValue(v)
+			if i := strings.LastIndexByte(file, ':'); i != -1 {
+				if _, err := strconv.Atoi(file[i+1:]); err == nil {
+					journalAppend(&buf, "CODE_LINE", file[i+1:])
+					file = file[:i]
+				}
+			}
+			journalAppend(&buf, "CODE_FILE", file)
+			delete(rec, keys[File])
+		}
+	}
+
+	for k, v := range rec {
+		name := journalField(k)
+		// Keeps the original message which is not used as the MESSAGE,
+		// for example the original message key "message" if the excerpt is used.
+		if name == "MESSAGE" && k == keys[Original] {
+			name = "MESSAGE_FULL"
+		}
+		// Skips the keys which collide with the fields above.
+		if name == "" || name == "PRIORITY" || name == "MESSAGE" || name == "CODE_FILE" || name == "CODE_LINE" {
+			continue
+		}
+		journalAppend(&buf, name, journalValue(v))
+	}
+
+	err = j.send(buf.Bytes())
+	if err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// Close closes the journal socket connection.
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.conn == nil {
+		return nil
+	}
+
+	err := j.conn.Close()
+	j.conn = nil
+	return err
+}
+
+func (j *Journal) send(p []byte) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.conn == nil {
+		addr := j.Addr
+		if addr == "" {
+			addr = JournalSocket
+		}
+
+		conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Net: "unixgram"})
+		if err != nil {
+			return err
+		}
+
+		j.conn = conn
+		j.addr = &net.UnixAddr{Name: addr, Net: "unixgram"}
+	}
+
+	_, _, err := j.conn.WriteMsgUnix(p, nil, j.addr)
+	if err == nil {
+		return nil
+	}
+
+	// Entries larger than the maximum datagram size are passed
+	// as a sealed memfd file descriptor instead of the datagram payload.
+	if !errors.Is(err, syscall.EMSGSIZE) && !errors.Is(err, syscall.ENOBUFS) {
+		return err
+	}
+
+	fd, err := unix.MemfdCreate("plog-journal", unix.MFD_CLOEXEC|unix.MFD_ALLOW_SEALING)
+	if err != nil {
+		return fmt.Errorf("journal memfd: %w", err)
+	}
+
+	f := os.NewFile(uintptr(fd), "plog-journal")
+	defer f.Close()
+
+	_, err = f.Write(p)
+	if err != nil {
+		return fmt.Errorf("journal memfd: %w", err)
+	}
+
+	_, err = unix.FcntlInt(f.Fd(), unix.F_ADD_SEALS, unix.F_SEAL_SHRINK|unix.F_SEAL_GROW|unix.F_SEAL_WRITE|unix.F_SEAL_SEAL)
+	if err != nil {
+		return fmt.Errorf("journal memfd: %w", err)
+	}
+
+	_, _, err = j.conn.WriteMsgUnix(nil, syscall.UnixRights(int(f.Fd())), j.addr)
+	return err
+}
+
+// journalAppend appends field to the native protocol entry,
+// values with new lines are serialized as a length prefixed binary data.
+func journalAppend(buf *bytes.Buffer, name, value string) {
+	buf.WriteString(name)
+
+	if strings.IndexByte(value, '\n') == -1 {
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+		return
+	}
+
+	buf.WriteByte('\n')
+	var n [8]byte
+	binary.LittleEndian.PutUint64(n[:], uint64(len(value)))
+	buf.Write(n[:])
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+// journalValue returns JSON string unquoted and any other JSON value as is.
+func journalValue(v json.RawMessage) string {
+	if len(v) > 0 && v[0] == '"' {
+		var s string
+		err := jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(v, &s)
+		if err == nil {
+			return s
+		}
+	}
+	return string(v)
+}
+
+// journalField returns the key upper-cased as a journal field name:
+// only A-Z, 0-9 and underscore, must not start with underscore or digit
+// (leading underscores are reserved for the trusted fields).
+func journalField(key string) string {
+	name := make([]byte, 0, len(key))
+	for _, c := range []byte(strings.ToUpper(key)) {
+		switch {
+		case c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+			name = append(name, c)
+		default:
+			name = append(name, '_')
+		}
+	}
+
+	name = bytes.TrimLeft(name, "_0123456789")
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return string(name)
+}
@@ -0,0 +1,175 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build linux
+// +build linux
+
+package plog_test
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"io"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/pfmt/pfmt"
+	"github.com/pfmt/plog"
+)
+
+func journalListen(t *testing.T) (*net.UnixConn, string) {
+	t.Helper()
+
+	addr := filepath.Join(t.TempDir(), "journal.sock")
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: addr, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("unwant listen error: %s", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return conn, addr
+}
+
+// journalRead reads datagram (or memfd passed as ancillary data)
+// and parses the native journal protocol fields.
+func journalRead(t *testing.T, conn *net.UnixConn) map[string]string {
+	t.Helper()
+
+	p := make([]byte, 1<<20)
+	oob := make([]byte, 1024)
+
+	n, oobn, _, _, err := conn.ReadMsgUnix(p, oob)
+	if err != nil {
+		t.Fatalf("unwant read error: %s", err)
+	}
+	p = p[:n]
+
+	if oobn != 0 {
+		msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+		if err != nil {
+			t.Fatalf("unwant control message error: %s", err)
+		}
+		fds, err := syscall.ParseUnixRights(&msgs[0])
+		if err != nil {
+			t.Fatalf("unwant unix rights error: %s", err)
+		}
+		f := os.NewFile(uintptr(fds[0]), "memfd")
+		defer f.Close()
+
+		_, err = f.Seek(0, io.SeekStart)
+		if err != nil {
+			t.Fatalf("unwant seek error: %s", err)
+		}
+		p, err = io.ReadAll(f)
+		if err != nil {
+			t.Fatalf("unwant memfd read error: %s", err)
+		}
+	}
+
+	fields := make(map[string]string)
+	for len(p) > 0 {
+		i := bytes.IndexAny(p, "=\n")
+		if i == -1 {
+			t.Fatalf("malformed entry: %q", p)
+		}
+		name := string(p[:i])
+		if p[i] == '=' {
+			j := bytes.IndexByte(p[i+1:], '\n')
+			fields[name] = string(p[i+1 : i+1+j])
+			p = p[i+1+j+1:]
+			continue
+		}
+		size := binary.LittleEndian.Uint64(p[i+1 : i+9])
+		fields[name] = string(p[i+9 : i+9+int(size)])
+		p = p[i+9+int(size)+1:]
+	}
+	return fields
+}
+
+func TestJournal(t *testing.T) {
+	conn, addr := journalListen(t)
+
+	j := &plog.Journal{
+		Addr: addr,
+		Keys: [4]encoding.TextMarshaler{pfmt.String("message"), pfmt.String("excerpt"), pfmt.String("trail"), pfmt.String("file")},
+	}
+	defer j.Close()
+
+	l := &plog.Log{
+		Output:  j,
+		Flag:    log.Lshortfile,
+		Keys:    j.Keys,
+		Trunc:   120,
+		Replace: [][2][]byte{[2][]byte{[]byte("\n"), []byte(" ")}},
+	}
+
+	l0 := l.Tee(plog.StringLevel("level", "err"), plog.StringString("request-id", "42"), plog.StringInt("_status", 500))
+	defer l0.Close()
+
+	_, err := l0.Write([]byte("main.go:42: Hello,\nJournal!"))
+	if err != nil {
+		t.Fatalf("unwant write error: %s", err)
+	}
+
+	got := journalRead(t, conn)
+
+	want := map[string]string{
+		"PRIORITY":     "3",
+		"MESSAGE":      "Hello, Journal!",
+		"MESSAGE_FULL": "main.go:42: Hello,\nJournal!",
+		"CODE_FILE":    "main.go",
+		"CODE_LINE":    "42",
+		"REQUEST_ID":   "42",
+		"STATUS":       "500",
+	}
+
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("want field %s=%q, got: %q", k, v, got[k])
+		}
+	}
+}
+
+func TestJournalMultilineValue(t *testing.T) {
+	conn, addr := journalListen(t)
+
+	j := &plog.Journal{Addr: addr, Keys: [4]encoding.TextMarshaler{pfmt.String("message")}}
+	defer j.Close()
+
+	_, err := (&plog.Log{Output: j, Keys: j.Keys}).Write([]byte("Hello,\nWorld!"))
+	if err != nil {
+		t.Fatalf("unwant write error: %s", err)
+	}
+
+	got := journalRead(t, conn)
+	if got["MESSAGE"] != "Hello,\nWorld!" {
+		t.Errorf("want multiline message, got: %q", got["MESSAGE"])
+	}
+}
+
+func TestJournalMemfd(t *testing.T) {
+	conn, addr := journalListen(t)
+
+	j := &plog.Journal{Addr: addr, Keys: [4]encoding.TextMarshaler{pfmt.String("message")}}
+	defer j.Close()
+
+	msg := strings.Repeat("x", 1<<19)
+
+	_, err := (&plog.Log{Output: j, Keys: j.Keys}).Write([]byte(msg))
+	if err != nil {
+		t.Fatalf("unwant write error: %s", err)
+	}
+
+	got := journalRead(t, conn)
+	if got["MESSAGE"] != msg {
+		t.Errorf("want %d bytes message, got: %d", len(msg), len(got["MESSAGE"]))
+	}
+}
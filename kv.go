@@ -0,0 +1,223 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plog
+
+import (
+	"encoding"
+	"encoding/json"
+	"time"
+
+	"github.com/pfmt/pfmt"
+)
+
+// Value is a type of the value of the generic key-value.
+// Slice of bytes is encoded as a string and slice of int32 as numbers,
+// use StringRunes for the slice of runes and StringError for the error.
+type Value interface {
+	bool | *bool | []bool | []*bool |
+		complex128 | *complex128 | []complex128 | []*complex128 |
+		complex64 | *complex64 | []complex64 | []*complex64 |
+		float32 | *float32 | []float32 | []*float32 |
+		float64 | *float64 | []float64 | []*float64 |
+		int | *int | []int | []*int |
+		int8 | *int8 | []int8 | []*int8 |
+		int16 | *int16 | []int16 | []*int16 |
+		int32 | *int32 | []int32 | []*int32 |
+		int64 | *int64 | []int64 | []*int64 |
+		string | *string | []string | []*string |
+		uint | *uint | []uint | []*uint |
+		uint8 | *uint8 | []*uint8 |
+		uint16 | *uint16 | []uint16 | []*uint16 |
+		uint32 | *uint32 | []uint32 | []*uint32 |
+		uint64 | *uint64 | []uint64 | []*uint64 |
+		uintptr | *uintptr | []uintptr | []*uintptr |
+		time.Duration | *time.Duration | []time.Duration | []*time.Duration |
+		time.Time | *time.Time | []time.Time | []*time.Time |
+		[]byte | *[]byte | [][]byte | []*[]byte |
+		*error | []error | []*error
+}
+
+// KV returns key-value pair of the string key and the typed value.
+func KV[T Value](k string, v T) kvm {
+	return kvm{K: pfmt.String(k), V: marshaler(v)}
+}
+
+// TextKV returns key-value pair of the text marshaler key and the typed value.
+func TextKV[T Value](k encoding.TextMarshaler, v T) kvm {
+	return kvm{K: k, V: marshaler(v)}
+}
+
+// marshaler returns marshaler specialized for the type of the value.
+// Marshaler is selected once by the constructor, so encoding does not convert
+// the value into the interface and the pointer to the value does not escape.
+func marshaler[T Value](v T) json.Marshaler {
+	switch p := any(&v).(type) {
+	case *bool:
+		return pfmt.Bool(*p)
+	case *[]bool:
+		return pfmt.Bools(*p)
+	case **bool:
+		return pfmt.Boolp(*p)
+	case *[]*bool:
+		return pfmt.Boolps(*p)
+	case *complex128:
+		return pfmt.Complex128(*p)
+	case *[]complex128:
+		return pfmt.Complex128s(*p)
+	case **complex128:
+		return pfmt.Complex128p(*p)
+	case *[]*complex128:
+		return pfmt.Complex128ps(*p)
+	case *complex64:
+		return pfmt.Complex64(*p)
+	case *[]complex64:
+		return pfmt.Complex64s(*p)
+	case **complex64:
+		return pfmt.Complex64p(*p)
+	case *[]*complex64:
+		return pfmt.Complex64ps(*p)
+	case *float32:
+		return pfmt.Float32(*p)
+	case *[]float32:
+		return pfmt.Float32s(*p)
+	case **float32:
+		return pfmt.Float32p(*p)
+	case *[]*float32:
+		return pfmt.Float32ps(*p)
+	case *float64:
+		return pfmt.Float64(*p)
+	case *[]float64:
+		return pfmt.Float64s(*p)
+	case **float64:
+		return pfmt.Float64p(*p)
+	case *[]*float64:
+		return pfmt.Float64ps(*p)
+	case *int:
+		return pfmt.Int(*p)
+	case *[]int:
+		return pfmt.Ints(*p)
+	case **int:
+		return pfmt.Intp(*p)
+	case *[]*int:
+		return pfmt.Intps(*p)
+	case *int8:
+		return pfmt.Int8(*p)
+	case *[]int8:
+		return pfmt.Int8s(*p)
+	case **int8:
+		return pfmt.Int8p(*p)
+	case *[]*int8:
+		return pfmt.Int8ps(*p)
+	case *int16:
+		return pfmt.Int16(*p)
+	case *[]int16:
+		return pfmt.Int16s(*p)
+	case **int16:
+		return pfmt.Int16p(*p)
+	case *[]*int16:
+		return pfmt.Int16ps(*p)
+	case *int32:
+		return pfmt.Int32(*p)
+	case *[]int32:
+		return pfmt.Int32s(*p)
+	case **int32:
+		return pfmt.Int32p(*p)
+	case *[]*int32:
+		return pfmt.Int32ps(*p)
+	case *int64:
+		return pfmt.Int64(*p)
+	case *[]int64:
+		return pfmt.Int64s(*p)
+	case **int64:
+		return pfmt.Int64p(*p)
+	case *[]*int64:
+		return pfmt.Int64ps(*p)
+	case *string:
+		return pfmt.String(*p)
+	case *[]string:
+		return pfmt.Strings(*p)
+	case **string:
+		return pfmt.Stringp(*p)
+	case *[]*string:
+		return pfmt.Stringps(*p)
+	case *uint:
+		return pfmt.Uint(*p)
+	case *[]uint:
+		return pfmt.Uints(*p)
+	case **uint:
+		return pfmt.Uintp(*p)
+	case *[]*uint:
+		return pfmt.Uintps(*p)
+	case *uint8:
+		return pfmt.Uint8(*p)
+	case **uint8:
+		return pfmt.Uint8p(*p)
+	case *[]*uint8:
+		return pfmt.Uint8ps(*p)
+	case *uint16:
+		return pfmt.Uint16(*p)
+	case *[]uint16:
+		return pfmt.Uint16s(*p)
+	case **uint16:
+		return pfmt.Uint16p(*p)
+	case *[]*uint16:
+		return pfmt.Uint16ps(*p)
+	case *uint32:
+		return pfmt.Uint32(*p)
+	case *[]uint32:
+		return pfmt.Uint32s(*p)
+	case **uint32:
+		return pfmt.Uint32p(*p)
+	case *[]*uint32:
+		return pfmt.Uint32ps(*p)
+	case *uint64:
+		return pfmt.Uint64(*p)
+	case *[]uint64:
+		return pfmt.Uint64s(*p)
+	case **uint64:
+		return pfmt.Uint64p(*p)
+	case *[]*uint64:
+		return pfmt.Uint64ps(*p)
+	case *uintptr:
+		return pfmt.Uintptr(*p)
+	case *[]uintptr:
+		return pfmt.Uintptrs(*p)
+	case **uintptr:
+		return pfmt.Uintptrp(*p)
+	case *[]*uintptr:
+		return pfmt.Uintptrps(*p)
+	case *time.Duration:
+		return pfmt.Duration(*p)
+	case *[]time.Duration:
+		return pfmt.Durations(*p)
+	case **time.Duration:
+		return pfmt.Durationp(*p)
+	case *[]*time.Duration:
+		return pfmt.Durationps(*p)
+	case *time.Time:
+		return pfmt.Time(*p)
+	case *[]time.Time:
+		return pfmt.Times(*p)
+	case **time.Time:
+		return pfmt.Timep(*p)
+	case *[]*time.Time:
+		return pfmt.Timeps(*p)
+	case *[]byte:
+		return pfmt.Bytes(*p)
+	case **[]byte:
+		return pfmt.Bytesp(*p)
+	case *[][]byte:
+		return pfmt.Bytess(*p)
+	case *[]*[]byte:
+		return pfmt.Bytesps(*p)
+	case **error:
+		return pfmt.Errp(*p)
+	case *[]error:
+		return pfmt.Errs(*p)
+	case *[]*error:
+		return pfmt.Errps(*p)
+	}
+	panic("plog: unsupported value type")
+}
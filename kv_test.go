@@ -0,0 +1,103 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plog_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kinbiko/jsonassert"
+	"github.com/pfmt/pfmt"
+	"github.com/pfmt/plog"
+)
+
+func TestGenericKV(t *testing.T) {
+	s := "foo"
+	n := 42
+	d := time.Second
+	err := errors.New("bar")
+
+	tests := []struct {
+		line  string
+		input pfmt.KV
+		want  string
+	}{
+		{line: line(), input: plog.KV("bool", true), want: `{"bool":true}`},
+		{line: line(), input: plog.KV("int", 42), want: `{"int":42}`},
+		{line: line(), input: plog.KV("int pointer", &n), want: `{"int pointer":42}`},
+		{line: line(), input: plog.KV("nil int pointer", (*int)(nil)), want: `{"nil int pointer":null}`},
+		{line: line(), input: plog.KV("int32 slice", []int32{1, 2}), want: `{"int32 slice":[1,2]}`},
+		{line: line(), input: plog.KV("uint8", uint8(7)), want: `{"uint8":7}`},
+		{line: line(), input: plog.KV("float64 slice", []float64{1.5, 2}), want: `{"float64 slice":[1.5,2]}`},
+		{line: line(), input: plog.KV("string", "foo"), want: `{"string":"foo"}`},
+		{line: line(), input: plog.KV("string pointers", []*string{&s, nil}), want: `{"string pointers":["foo",null]}`},
+		{line: line(), input: plog.KV("bytes", []byte("foo")), want: `{"bytes":"foo"}`},
+		{line: line(), input: plog.KV("duration", &d), want: `{"duration":"1s"}`},
+		{line: line(), input: plog.KV("time", time.Date(1970, time.January, 1, 0, 0, 0, 42, time.UTC)), want: `{"time":"1970-01-01T00:00:00.000000042Z"}`},
+		{line: line(), input: plog.KV("errors", []error{err}), want: `{"errors":["bar"]}`},
+		{line: line(), input: plog.TextKV(pfmt.String("text key"), uint64(1)), want: `{"text key":1}`},
+		{line: line(), input: plog.TextStrings(pfmt.String("text strings"), []string{"a", "b"}), want: `{"text strings":["a","b"]}`},
+		{line: line(), input: plog.TextErrors(pfmt.String("text errors"), []error{err}), want: `{"text errors":["bar"]}`},
+		{line: line(), input: plog.TextBytess(pfmt.String("text bytess"), [][]byte{[]byte("a")}), want: `{"text bytess":["a"]}`},
+		{line: line(), input: plog.TextKVFunc(pfmt.String("text func"), func() pfmt.KV { return pfmt.Int(n) }), want: `{"text func":42}`},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.line, func(t *testing.T) {
+			t.Parallel()
+
+			txt, err := tt.input.MarshalText()
+			if err != nil {
+				t.Fatalf("encoding marshal text error: %s", err)
+			}
+
+			jsn, err := json.Marshal(map[string]json.Marshaler{string(txt): tt.input})
+			if err != nil {
+				t.Fatalf("unwant marshal error: %s", err)
+			}
+
+			ja := jsonassert.New(testprinter{t: t, link: tt.line})
+			ja.Assertf(string(jsn), tt.want)
+		})
+	}
+}
+
+// BenchmarkKV compares encoding of the generic key-values
+// with encoding of the specialized pfmt marshalers.
+func BenchmarkKV(b *testing.B) {
+	s := "hello world"
+	f := 3.14
+	ints := []int{1, 2, 3}
+
+	benchmarks := []struct {
+		name string
+		kv   func() json.Marshaler
+	}{
+		{name: "string", kv: func() json.Marshaler { return plog.KV("k", s) }},
+		{name: "pfmt string", kv: func() json.Marshaler { return pfmt.String(s) }},
+		{name: "float64", kv: func() json.Marshaler { return plog.KV("k", f) }},
+		{name: "pfmt float64", kv: func() json.Marshaler { return pfmt.Float64(f) }},
+		{name: "ints", kv: func() json.Marshaler { return plog.KV("k", ints) }},
+		{name: "pfmt ints", kv: func() json.Marshaler { return pfmt.Ints(ints) }},
+	}
+
+	for _, bb := range benchmarks {
+		bb := bb
+		b.Run(bb.name, func(b *testing.B) {
+			kv := bb.kv()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, err := kv.MarshalJSON()
+				if err != nil {
+					b.Fatalf("unwant marshal error: %s", err)
+				}
+			}
+		})
+	}
+}
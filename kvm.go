@@ -23,111 +23,114 @@ func (kv kvm) MarshalText() (text []byte, err error) { return kv.K.MarshalText()
 func (kv kvm) MarshalJSON() ([]byte, error)          { return kv.V.MarshalJSON() }
 
 func StringBool(k string, v bool) kvm {
-	return kvm{K: pfmt.String(k), V: pfmt.Bool(v)}
+	return KV(k, v)
 }
 
 func StringBools(k string, v []bool) kvm {
-	return kvm{K: pfmt.String(k), V: pfmt.Bools(v)}
+	return KV(k, v)
 }
 
 func StringBoolp(k string, v *bool) kvm {
-	return kvm{K: pfmt.String(k), V: pfmt.Boolp(v)}
+	return KV(k, v)
 }
 
 func StringBytes(k string, v []byte) kvm {
-	return kvm{K: pfmt.String(k), V: pfmt.Bytes(v)}
+	return KV(k, v)
 }
 
 func StringBytess(k string, v [][]byte) kvm {
-	return kvm{K: pfmt.String(k), V: pfmt.Bytess(v)}
+	return KV(k, v)
 }
 
 func StringBytesp(k string, v *[]byte) kvm {
-	return kvm{K: pfmt.String(k), V: pfmt.Bytesp(v)}
+	return KV(k, v)
 }
 
 func StringBytessp(k string, v []*[]byte) kvm {
-	return kvm{K: pfmt.String(k), V: pfmt.Bytesps(v)}
+	return KV(k, v)
 }
 
 func StringComplex128(k string, v complex128) kvm {
-	return kvm{K: pfmt.String(k), V: pfmt.Complex128(v)}
+	return KV(k, v)
 }
 
 func StringComplex128p(k string, v *complex128) kvm {
-	return kvm{K: pfmt.String(k), V: pfmt.Complex128p(v)}
+	return KV(k, v)
 }
 
 func StringComplex64(k string, v complex64) kvm {
-	return kvm{K: pfmt.String(k), V: pfmt.Complex64(v)}
+	return KV(k, v)
 }
 
 func StringComplex64p(k string, v *complex64) kvm {
-	return kvm{K: pfmt.String(k), V: pfmt.Complex64p(v)}
+	return KV(k, v)
 }
 
+// StringError encodes the message of the error only,
+// use StringErrorObject for the type, the chain of the wrapped errors,
+// the stack trace and the key-values of the error.
 func StringError(k string, v error) kvm {
 	return kvm{K: pfmt.String(k), V: pfmt.Err(v)}
 }
 
 func StringErrors(k string, v []error) kvm {
-	return kvm{K: pfmt.String(k), V: pfmt.Errs(v)}
+	return KV(k, v)
 }
 
 func StringFloat32(k string, v float32) kvm {
-	return kvm{K: pfmt.String(k), V: pfmt.Float32(v)}
+	return KV(k, v)
 }
 
 func StringFloat32p(k string, v *float32) kvm {
-	return kvm{K: pfmt.String(k), V: pfmt.Float32p(v)}
+	return KV(k, v)
 }
 
 func StringFloat64(k string, v float64) kvm {
-	return kvm{K: pfmt.String(k), V: pfmt.Float64(v)}
+	return KV(k, v)
 }
 
 func StringFloat64p(k string, v *float64) kvm {
-	return kvm{K: pfmt.String(k), V: pfmt.Float64p(v)}
+	return KV(k, v)
 }
 
 func StringInt(k string, v int) kvm {
-	return kvm{K: pfmt.String(k), V: pfmt.Int(v)}
+	return KV(k, v)
 }
 
 func StringIntp(k string, v *int) kvm {
-	return kvm{K: pfmt.String(k), V: pfmt.Intp(v)}
+	return KV(k, v)
 }
 
 func StringInt16(k string, v int16) kvm {
-	return kvm{K: pfmt.String(k), V: pfmt.Int16(v)}
+	return KV(k, v)
 }
 
 func StringInt16p(k string, v *int16) kvm {
-	return kvm{K: pfmt.String(k), V: pfmt.Int16p(v)}
+	return KV(k, v)
 }
 
 func StringInt32(k string, v int32) kvm {
-	return kvm{K: pfmt.String(k), V: pfmt.Int32(v)}
+	return KV(k, v)
 }
 
 func StringInt32p(k string, v *int32) kvm {
-	return kvm{K: pfmt.String(k), V: pfmt.Int32p(v)}
+	return KV(k, v)
 }
 
 func StringInt64(k string, v int64) kvm {
-	return kvm{K: pfmt.String(k), V: pfmt.Int64(v)}
+	return KV(k, v)
 }
 
 func StringInt64p(k string, v *int64) kvm {
-	return kvm{K: pfmt.String(k), V: pfmt.Int64p(v)}
+	return KV(k, v)
 }
 
 func StringInt8(k string, v int8) kvm {
-	return kvm{K: pfmt.String(k), V: pfmt.Int8(v)}
+	return KV(k, v)
 }
 
 func StringInt8p(k string, v *int8) kvm {
-	return kvm{K: pfmt.String(k), V: pfmt.Int8p(v)}
+	return KV(k, v)
 }
 
 func StringRunes(k string, v []rune) kvm {
@@ -139,79 +142,79 @@ func StringRunesp(k string, v *[]rune) kvm {
 }
 
 func StringString(k string, v string) kvm {
-	return kvm{K: pfmt.String(k), V: pfmt.String(v)}
+	return KV(k, v)
 }
 
 func StringStrings(k string, v []string) kvm {
-	return kvm{K: pfmt.String(k), V: pfmt.Strings(v)}
+	return KV(k, v)
 }
 
 func StringStringp(k string, v *string) kvm {
-	return kvm{K: pfmt.String(k), V: pfmt.Stringp(v)}
+	return KV(k, v)
 }
 
 func StringUint(k string, v uint) kvm {
-	return kvm{K: pfmt.String(k), V: pfmt.Uint(v)}
+	return KV(k, v)
 }
 
 func StringUintp(k string, v *uint) kvm {
-	return kvm{K: pfmt.String(k), V: pfmt.Uintp(v)}
+	return KV(k, v)
 }
 
 func StringUint16(k string, v uint16) kvm {
-	return kvm{K: pfmt.String(k), V: pfmt.Uint16(v)}
+	return KV(k, v)
 }
 
 func StringUint16p(k string, v *uint16) kvm {
-	return kvm{K: pfmt.String(k), V: pfmt.Uint16p(v)}
+	return KV(k, v)
 }
 
 func StringUint32(k string, v uint32) kvm {
-	return kvm{K: pfmt.String(k), V: pfmt.Uint32(v)}
+	return KV(k, v)
 }
 
 func StringUint32p(k string, v *uint32) kvm {
-	return kvm{K: pfmt.String(k), V: pfmt.Uint32p(v)}
+	return KV(k, v)
 }
 
 func StringUint64(k string, v uint64) kvm {
-	return kvm{K: pfmt.String(k), V: pfmt.Uint64(v)}
+	return KV(k, v)
 }
 
 func StringUint64p(k string, v *uint64) kvm {
-	return kvm{K: pfmt.String(k), V: pfmt.Uint64p(v)}
+	return KV(k, v)
 }
 
 func StringUint8(k string, v uint8) kvm {
-	return kvm{K: pfmt.String(k), V: pfmt.Uint8(v)}
+	return KV(k, v)
 }
 
 func StringUint8p(k string, v *uint8) kvm {
-	return kvm{K: pfmt.String(k), V: pfmt.Uint8p(v)}
+	return KV(k, v)
 }
 
 func StringUintptr(k string, v uintptr) kvm {
-	return kvm{K: pfmt.String(k), V: pfmt.Uintptr(v)}
+	return KV(k, v)
 }
 
 func StringUintptrp(k string, v *uintptr) kvm {
-	return kvm{K: pfmt.String(k), V: pfmt.Uintptrp(v)}
+	return KV(k, v)
 }
 
 func StringDuration(k string, v time.Duration) kvm {
-	return kvm{K: pfmt.String(k), V: pfmt.Duration(v)}
+	return KV(k, v)
 }
 
 func StringDurationp(k string, v *time.Duration) kvm {
-	return kvm{K: pfmt.String(k), V: pfmt.Durationp(v)}
+	return KV(k, v)
 }
 
 func StringTime(k string, v time.Time) kvm {
-	return kvm{K: pfmt.String(k), V: pfmt.Time(v)}
+	return KV(k, v)
 }
 
 func StringTimep(k string, v *time.Time) kvm {
-	return kvm{K: pfmt.String(k), V: pfmt.Timep(v)}
+	return KV(k, v)
 }
 
 func StringFunc(k string, v func() pfmt.KV) kvm {
@@ -231,95 +234,114 @@ func StringReflect(k string, v interface{}) kvm {
 }
 
 func TextBool(k encoding.TextMarshaler, v bool) kvm {
-	return kvm{K: k, V: pfmt.Bool(v)}
+	return TextKV(k, v)
+}
+
+func TextBools(k encoding.TextMarshaler, v []bool) kvm {
+	return TextKV(k, v)
 }
 
 func TextBoolp(k encoding.TextMarshaler, v *bool) kvm {
-	return kvm{K: k, V: pfmt.Boolp(v)}
+	return TextKV(k, v)
 }
 
 func TextBytes(k encoding.TextMarshaler, v []byte) kvm {
-	return kvm{K: k, V: pfmt.Bytes(v)}
+	return TextKV(k, v)
+}
+
+func TextBytess(k encoding.TextMarshaler, v [][]byte) kvm {
+	return TextKV(k, v)
 }
 
 func TextBytesp(k encoding.TextMarshaler, v *[]byte) kvm {
-	return kvm{K: k, V: pfmt.Bytesp(v)}
+	return TextKV(k, v)
+}
+
+func TextBytessp(k encoding.TextMarshaler, v []*[]byte) kvm {
+	return TextKV(k, v)
 }
 
 func TextComplex128(k encoding.TextMarshaler, v complex128) kvm {
-	return kvm{K: k, V: pfmt.Complex128(v)}
+	return TextKV(k, v)
 }
 
 func TextComplex128p(k encoding.TextMarshaler, v *complex128) kvm {
-	return kvm{K: k, V: pfmt.Complex128p(v)}
+	return TextKV(k, v)
 }
 
 func TextComplex64(k encoding.TextMarshaler, v complex64) kvm {
-	return kvm{K: k, V: pfmt.Complex64(v)}
+	return TextKV(k, v)
 }
 
 func TextComplex64p(k encoding.TextMarshaler, v *complex64) kvm {
-	return kvm{K: k, V: pfmt.Complex64p(v)}
+	return TextKV(k, v)
 }
 
+// TextError encodes the message of the error only,
+// use TextErrorObject for the type, the chain of the wrapped errors,
+// the stack trace and the key-values of the error.
 func TextError(k encoding.TextMarshaler, v error) kvm {
 	return kvm{K: k, V: pfmt.Err(v)}
 }
 
+func TextErrors(k encoding.TextMarshaler, v []error) kvm {
+	return TextKV(k, v)
+}
+
 func TextFloat32(k encoding.TextMarshaler, v float32) kvm {
-	return kvm{K: k, V: pfmt.Float32(v)}
+	return TextKV(k, v)
 }
 
 func TextFloat32p(k encoding.TextMarshaler, v *float32) kvm {
-	return kvm{K: k, V: pfmt.Float32p(v)}
+	return TextKV(k, v)
 }
 
 func TextFloat64(k encoding.TextMarshaler, v float64) kvm {
-	return kvm{K: k, V: pfmt.Float64(v)}
+	return TextKV(k, v)
 }
 
 func TextFloat64p(k encoding.TextMarshaler, v *float64) kvm {
-	return kvm{K: k, V: pfmt.Float64p(v)}
+	return TextKV(k, v)
 }
 
 func TextInt(k encoding.TextMarshaler, v int) kvm {
-	return kvm{K: k, V: pfmt.Int(v)}
+	return TextKV(k, v)
 }
 
 func TextIntp(k encoding.TextMarshaler, v *int) kvm {
-	return kvm{K: k, V: pfmt.Intp(v)}
+	return TextKV(k, v)
 }
 
 func TextInt16(k encoding.TextMarshaler, v int16) kvm {
-	return kvm{K: k, V: pfmt.Int16(v)}
+	return TextKV(k, v)
 }
 
 func TextInt16p(k encoding.TextMarshaler, v *int16) kvm {
-	return kvm{K: k, V: pfmt.Int16p(v)}
+	return TextKV(k, v)
 }
 
 func TextInt32(k encoding.TextMarshaler, v int32) kvm {
-	return kvm{K: k, V: pfmt.Int32(v)}
+	return TextKV(k, v)
 }
 
 func TextInt32p(k encoding.TextMarshaler, v *int32) kvm {
-	return kvm{K: k, V: pfmt.Int32p(v)}
+	return TextKV(k, v)
 }
 
 func TextInt64(k encoding.TextMarshaler, v int64) kvm {
-	return kvm{K: k, V: pfmt.Int64(v)}
+	return TextKV(k, v)
 }
 
 func TextInt64p(k encoding.TextMarshaler, v *int64) kvm {
-	return kvm{K: k, V: pfmt.Int64p(v)}
+	return TextKV(k, v)
 }
 
 func TextInt8(k encoding.TextMarshaler, v int8) kvm {
-	return kvm{K: k, V: pfmt.Int8(v)}
+	return TextKV(k, v)
 }
 
 func TextInt8p(k encoding.TextMarshaler, v *int8) kvm {
-	return kvm{K: k, V: pfmt.Int8p(v)}
+	return TextKV(k, v)
 }
 
 func TextRunes(k encoding.TextMarshaler, v []rune) kvm {
@@ -335,81 +357,92 @@ func TextText(k, v encoding.TextMarshaler) kvm {
 }
 
 func TextString(k encoding.TextMarshaler, v string) kvm {
-	return kvm{K: k, V: pfmt.String(v)}
+	return TextKV(k, v)
+}
+
+func TextStrings(k encoding.TextMarshaler, v []string) kvm {
+	return TextKV(k, v)
 }
 
 func TextStringp(k encoding.TextMarshaler, v *string) kvm {
-	return kvm{K: k, V: pfmt.Stringp(v)}
+	return TextKV(k, v)
 }
 
 func TextUint(k encoding.TextMarshaler, v uint) kvm {
-	return kvm{K: k, V: pfmt.Uint(v)}
+	return TextKV(k, v)
 }
 
 func TextUintp(k encoding.TextMarshaler, v *uint) kvm {
-	return kvm{K: k, V: pfmt.Uintp(v)}
+	return TextKV(k, v)
 }
 
 func TextUint16(k encoding.TextMarshaler, v uint16) kvm {
-	return kvm{K: k, V: pfmt.Uint16(v)}
+	return TextKV(k, v)
 }
 
 func TextUint16p(k encoding.TextMarshaler, v *uint16) kvm {
-	return kvm{K: k, V: pfmt.Uint16p(v)}
+	return TextKV(k, v)
 }
 
 func TextUint32(k encoding.TextMarshaler, v uint32) kvm {
-	return kvm{K: k, V: pfmt.Uint32(v)}
+	return TextKV(k, v)
 }
 
 func TextUint32p(k encoding.TextMarshaler, v *uint32) kvm {
-	return kvm{K: k, V: pfmt.Uint32p(v)}
+	return TextKV(k, v)
 }
 
 func TextUint64(k encoding.TextMarshaler, v uint64) kvm {
-	return kvm{K: k, V: pfmt.Uint64(v)}
+	return TextKV(k, v)
 }
 
 func TextUint64p(k encoding.TextMarshaler, v *uint64) kvm {
-	return kvm{K: k, V: pfmt.Uint64p(v)}
+	return TextKV(k, v)
 }
 
 func TextUint8(k encoding.TextMarshaler, v uint8) kvm {
-	return kvm{K: k, V: pfmt.Uint8(v)}
+	return TextKV(k, v)
 }
 
 func TextUint8p(k encoding.TextMarshaler, v *uint8) kvm {
-	return kvm{K: k, V: pfmt.Uint8p(v)}
+	return TextKV(k, v)
 }
 
 func TextUintptr(k encoding.TextMarshaler, v uintptr) kvm {
-	return kvm{K: k, V: pfmt.Uintptr(v)}
+	return TextKV(k, v)
 }
 
 func TextUintptrp(k encoding.TextMarshaler, v *uintptr) kvm {
-	return kvm{K: k, V: pfmt.Uintptrp(v)}
+	return TextKV(k, v)
 }
 
 func TextDuration(k encoding.TextMarshaler, v time.Duration) kvm {
-	return kvm{K: k, V: pfmt.Duration(v)}
+	return TextKV(k, v)
 }
 
 func TextDurationp(k encoding.TextMarshaler, v *time.Duration) kvm {
-	return kvm{K: k, V: pfmt.Durationp(v)}
+	return TextKV(k, v)
 }
 
 func TextTime(k encoding.TextMarshaler, v time.Time) kvm {
-	return kvm{K: k, V: pfmt.Time(v)}
+	return TextKV(k, v)
 }
 
 func TextTimep(k encoding.TextMarshaler, v *time.Time) kvm {
-	return kvm{K: k, V: pfmt.Timep(v)}
+	return TextKV(k, v)
 }
 
+// TextFunc evaluates the function immediately, unlike StringFunc,
+// use TextKVFunc for the lazy evaluation of the text marshaler key.
 func TextFunc(k encoding.TextMarshaler, v func() json.Marshaler) kvm {
 	return kvm{K: k, V: v()}
 }
 
+// TextKVFunc evaluates the function on each encoding like StringFunc does.
+func TextKVFunc(k encoding.TextMarshaler, v func() pfmt.KV) kvm {
+	return kvm{K: k, V: pfmt.KVFunc(v)}
+}
+
 func TextRaw(k encoding.TextMarshaler, v []byte) kvm {
 	return kvm{K: k, V: pfmt.Raw(v)}
 }
@@ -425,14 +458,17 @@ func TextReflect(k encoding.TextMarshaler, v interface{}) kvm {
 // kvl is a key-value pair implements the Leveler interface
 // in addition to the KV interface (text/json marshalers).
 // Level method intends to indicate severity level.
-// For example syslog levels: "0" emergency;
-//               						  "1" alert;
-//               						  "2" critical;
-//               						  "3" error;
-//               						  "4" warning;
-//               						  "5" notice;
-//               						  "6" informational;
-//               						  "7" debug;
+// For example syslog levels:
+//
+//	"0" emergency;
+//	"1" alert;
+//	"2" critical;
+//	"3" error;
+//	"4" warning;
+//	"5" notice;
+//	"6" informational;
+//	"7" debug;
+//
 // (https://en.wikipedia.org/wiki/Syslog#Severity_level).
 type kvl struct {
 	K encoding.TextMarshaler
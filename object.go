@@ -0,0 +1,240 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plog
+
+import (
+	"encoding"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/pfmt/pfmt"
+)
+
+// ObjectMarshaler is a type which writes its own fields
+// through the encoder without reflection.
+type ObjectMarshaler interface {
+	MarshalLogObject(ObjectEncoder) error
+}
+
+// ArrayMarshaler is a type which writes its own elements
+// through the encoder without reflection.
+type ArrayMarshaler interface {
+	MarshalLogArray(ArrayEncoder) error
+}
+
+// ObjectMarshalerFunc is an adapter to allow the use of ordinary functions as object marshalers.
+type ObjectMarshalerFunc func(ObjectEncoder) error
+
+// MarshalLogObject calls f(enc).
+func (f ObjectMarshalerFunc) MarshalLogObject(enc ObjectEncoder) error { return f(enc) }
+
+// ArrayMarshalerFunc is an adapter to allow the use of ordinary functions as array marshalers.
+type ArrayMarshalerFunc func(ArrayEncoder) error
+
+// MarshalLogArray calls f(enc).
+func (f ArrayMarshalerFunc) MarshalLogArray(enc ArrayEncoder) error { return f(enc) }
+
+// ObjectEncoder is a typed encoder of the object fields.
+type ObjectEncoder interface {
+	AddString(k, v string)
+	AddBytes(k string, v []byte)
+	AddBool(k string, v bool)
+	AddInt64(k string, v int64)
+	AddUint64(k string, v uint64)
+	AddFloat64(k string, v float64)
+	AddDuration(k string, v time.Duration)
+	AddTime(k string, v time.Time)
+	AddObject(k string, v ObjectMarshaler) error
+	AddArray(k string, v ArrayMarshaler) error
+}
+
+// ArrayEncoder is a typed encoder of the array elements.
+type ArrayEncoder interface {
+	AppendString(v string)
+	AppendBytes(v []byte)
+	AppendBool(v bool)
+	AppendInt64(v int64)
+	AppendUint64(v uint64)
+	AppendFloat64(v float64)
+	AppendDuration(v time.Duration)
+	AppendTime(v time.Time)
+	AppendObject(v ObjectMarshaler) error
+	AppendArray(v ArrayMarshaler) error
+}
+
+func StringObject(k string, v ObjectMarshaler) kvm {
+	return kvm{K: pfmt.String(k), V: objectJSON{v: v}}
+}
+
+func StringArray(k string, v ArrayMarshaler) kvm {
+	return kvm{K: pfmt.String(k), V: arrayJSON{v: v}}
+}
+
+func TextObject(k encoding.TextMarshaler, v ObjectMarshaler) kvm {
+	return kvm{K: k, V: objectJSON{v: v}}
+}
+
+func TextArray(k encoding.TextMarshaler, v ArrayMarshaler) kvm {
+	return kvm{K: k, V: arrayJSON{v: v}}
+}
+
+// objectJSON is a JSON marshaler of the object marshaler.
+type objectJSON struct{ v ObjectMarshaler }
+
+func (o objectJSON) MarshalJSON() ([]byte, error) {
+	var enc jsonEncoder
+	err := enc.object(o.v)
+	return enc.p, err
+}
+
+// arrayJSON is a JSON marshaler of the array marshaler.
+type arrayJSON struct{ v ArrayMarshaler }
+
+func (a arrayJSON) MarshalJSON() ([]byte, error) {
+	var enc jsonEncoder
+	err := enc.array(a.v)
+	return enc.p, err
+}
+
+// jsonEncoder is a JSON object and array encoder.
+type jsonEncoder struct {
+	p     []byte
+	comma bool
+}
+
+// object appends the object or null if object marshaler is nil.
+func (e *jsonEncoder) object(v ObjectMarshaler) error {
+	if v == nil {
+		e.p = append(e.p, "null"...)
+		return nil
+	}
+	comma := e.comma
+	e.p = append(e.p, '{')
+	e.comma = false
+	err := v.MarshalLogObject(e)
+	e.p = append(e.p, '}')
+	e.comma = comma
+	return err
+}
+
+// array appends the array or null if array marshaler is nil.
+func (e *jsonEncoder) array(v ArrayMarshaler) error {
+	if v == nil {
+		e.p = append(e.p, "null"...)
+		return nil
+	}
+	comma := e.comma
+	e.p = append(e.p, '[')
+	e.comma = false
+	err := v.MarshalLogArray(e)
+	e.p = append(e.p, ']')
+	e.comma = comma
+	return err
+}
+
+// key appends separator of the previous field and the key,
+// so the value is appended without separator.
+func (e *jsonEncoder) key(k string) {
+	e.elem()
+	e.p, _ = appendSafe(e.p, k)
+	e.p = append(e.p, ':')
+	e.comma = false
+}
+
+// elem appends separator of the previous element.
+func (e *jsonEncoder) elem() {
+	if e.comma {
+		e.p = append(e.p, ',')
+	}
+	e.comma = true
+}
+
+func (e *jsonEncoder) AddString(k, v string)                 { e.key(k); e.AppendString(v) }
+func (e *jsonEncoder) AddBytes(k string, v []byte)           { e.key(k); e.AppendBytes(v) }
+func (e *jsonEncoder) AddBool(k string, v bool)              { e.key(k); e.AppendBool(v) }
+func (e *jsonEncoder) AddInt64(k string, v int64)            { e.key(k); e.AppendInt64(v) }
+func (e *jsonEncoder) AddUint64(k string, v uint64)          { e.key(k); e.AppendUint64(v) }
+func (e *jsonEncoder) AddFloat64(k string, v float64)        { e.key(k); e.AppendFloat64(v) }
+func (e *jsonEncoder) AddDuration(k string, v time.Duration) { e.key(k); e.AppendDuration(v) }
+func (e *jsonEncoder) AddTime(k string, v time.Time)         { e.key(k); e.AppendTime(v) }
+
+func (e *jsonEncoder) AddObject(k string, v ObjectMarshaler) error {
+	e.key(k)
+	return e.AppendObject(v)
+}
+
+func (e *jsonEncoder) AddArray(k string, v ArrayMarshaler) error {
+	e.key(k)
+	return e.AppendArray(v)
+}
+
+func (e *jsonEncoder) AppendString(v string) {
+	e.elem()
+	e.p, _ = appendSafe(e.p, v)
+}
+
+func (e *jsonEncoder) AppendBytes(v []byte) {
+	e.elem()
+	e.p, _ = appendSafe(e.p, string(v))
+}
+
+func (e *jsonEncoder) AppendBool(v bool) {
+	e.elem()
+	e.p = strconv.AppendBool(e.p, v)
+}
+
+func (e *jsonEncoder) AppendInt64(v int64) {
+	e.elem()
+	e.p = strconv.AppendInt(e.p, v, 10)
+}
+
+func (e *jsonEncoder) AppendUint64(v uint64) {
+	e.elem()
+	e.p = strconv.AppendUint(e.p, v, 10)
+}
+
+// AppendFloat64 appends NaN and infinities as strings, JSON has no such numbers.
+func (e *jsonEncoder) AppendFloat64(v float64) {
+	e.elem()
+	switch {
+	case math.IsNaN(v):
+		e.p = append(e.p, `"NaN"`...)
+	case math.IsInf(v, 1):
+		e.p = append(e.p, `"+Inf"`...)
+	case math.IsInf(v, -1):
+		e.p = append(e.p, `"-Inf"`...)
+	default:
+		e.p = strconv.AppendFloat(e.p, v, 'g', -1, 64)
+	}
+}
+
+func (e *jsonEncoder) AppendDuration(v time.Duration) {
+	e.elem()
+	e.p, _ = appendSafe(e.p, v.String())
+}
+
+func (e *jsonEncoder) AppendTime(v time.Time) {
+	e.elem()
+	e.p = append(e.p, '"')
+	e.p = v.AppendFormat(e.p, time.RFC3339Nano)
+	e.p = append(e.p, '"')
+}
+
+func (e *jsonEncoder) AppendObject(v ObjectMarshaler) error {
+	e.elem()
+	return e.object(v)
+}
+
+func (e *jsonEncoder) AppendArray(v ArrayMarshaler) error {
+	e.elem()
+	return e.array(v)
+}
+
+// appendSafe appends JSON string.
+func appendSafe(dst []byte, s string) ([]byte, error) {
+	p, err := safeBytes(s).MarshalJSON()
+	return append(dst, p...), err
+}
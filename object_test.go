@@ -0,0 +1,136 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plog_test
+
+import (
+	"bytes"
+	"encoding"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/kinbiko/jsonassert"
+	"github.com/pfmt/pfmt"
+	"github.com/pfmt/plog"
+)
+
+type user struct {
+	name   string
+	age    int
+	roles  roles
+	joined time.Time
+	token  string
+}
+
+func (u user) MarshalLogObject(enc plog.ObjectEncoder) error {
+	enc.AddString("name", u.name)
+	enc.AddInt64("age", int64(u.age))
+	err := enc.AddArray("roles", u.roles)
+	if err != nil {
+		return err
+	}
+	enc.AddTime("joined", u.joined)
+	return nil
+}
+
+type roles []string
+
+func (r roles) MarshalLogArray(enc plog.ArrayEncoder) error {
+	for _, s := range r {
+		enc.AppendString(s)
+	}
+	return nil
+}
+
+func TestObject(t *testing.T) {
+	joined := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		line string
+		kv   []pfmt.KV
+		want string
+		err  bool
+	}{
+		{
+			name: "object with nested array",
+			line: line(),
+			kv: []pfmt.KV{
+				plog.StringObject("user", user{name: "Alice \"A\"", age: 42, roles: roles{"admin", "dev"}, joined: joined, token: "secret"}),
+			},
+			want: `{
+				"message":"Hello, World!",
+				"user":{"name":"Alice \"A\"","age":42,"roles":["admin","dev"],"joined":"2022-01-02T03:04:05Z"}
+			}`,
+		}, {
+			name: "array of objects and scalars",
+			line: line(),
+			kv: []pfmt.KV{
+				plog.StringArray("items", plog.ArrayMarshalerFunc(func(enc plog.ArrayEncoder) error {
+					enc.AppendBool(true)
+					enc.AppendFloat64(1.5)
+					enc.AppendFloat64(math.Inf(1))
+					enc.AppendDuration(time.Second)
+					err := enc.AppendObject(plog.ObjectMarshalerFunc(func(enc plog.ObjectEncoder) error {
+						enc.AddUint64("id", 1)
+						return enc.AddObject("empty", plog.ObjectMarshalerFunc(func(plog.ObjectEncoder) error { return nil }))
+					}))
+					if err != nil {
+						return err
+					}
+					return enc.AppendArray(nil)
+				})),
+			},
+			want: `{
+				"message":"Hello, World!",
+				"items":[true,1.5,"+Inf","1s",{"id":1,"empty":{}},null]
+			}`,
+		}, {
+			name: "nil object",
+			line: line(),
+			kv:   []pfmt.KV{plog.StringObject("user", nil)},
+			want: `{
+				"message":"Hello, World!",
+				"user":null
+			}`,
+		}, {
+			name: "object error",
+			line: line(),
+			kv: []pfmt.KV{plog.StringObject("user", plog.ObjectMarshalerFunc(func(plog.ObjectEncoder) error {
+				return errors.New("broken")
+			}))},
+			err: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.line+"/"+tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+
+			l := &plog.Log{
+				Output: &buf,
+				Keys:   [4]encoding.TextMarshaler{pfmt.String("message")},
+			}
+
+			_, err := l.Tee(tt.kv...).Write([]byte("Hello, World!"))
+			if tt.err {
+				if err == nil {
+					t.Fatalf("%s: want write error", tt.line)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unwant write error: %s", err)
+			}
+
+			ja := jsonassert.New(testprinter{t: t, link: tt.line})
+			ja.Assertf(buf.String(), tt.want)
+		})
+	}
+}
@@ -7,11 +7,18 @@ package plog
 
 import (
 	"bytes"
+	"context"
 	"encoding"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"log"
+	"os"
+	"reflect"
+	"strings"
 	"sync"
+	"syscall"
 	"time"
 	"unicode"
 	"unicode/utf8"
@@ -43,15 +50,38 @@ func New(opts ...Option) Log {
 
 // Log is a JSON logger/writer.
 type Log struct {
-	Output  io.Writer                             // Output is a destination for output.
-	Flag    int                                   // Flag is a log properties.
-	KV      []pfmt.KV                             // KV is a key-values.
-	Level   func(level string) (output io.Writer) // Level function receives severity level and returns a output writer for a severity level.
-	Keys    [4]encoding.TextMarshaler             // Keys: 0 = original message; 1 = message excerpt; 2 = message trail; 3 = file path.
-	Key     uint8                                 // Key is a default/sticky message key: all except 0 = original message; 1 = message excerpt.
-	Trunc   int                                   // Trunc is a maximum length of an excerpt, after which it is truncated.
-	Marks   [3][]byte                             // Marks: 0 = truncate; 1 = empty; 2 = blank.
-	Replace [][2][]byte                           // Replace ia a pairs of byte slices to replace in the message excerpt.
+	Output     io.Writer                             // Output is a destination for output.
+	Flag       int                                   // Flag is a log properties.
+	KV         []pfmt.KV                             // KV is a key-values.
+	Level      func(level string) (output io.Writer) // Level function receives severity level and returns a output writer for a severity level.
+	Keys       [4]encoding.TextMarshaler             // Keys: 0 = original message; 1 = message excerpt; 2 = message trail; 3 = file path.
+	Key        uint8                                 // Key is a default/sticky message key: all except 0 = original message; 1 = message excerpt.
+	Trunc      int                                   // Trunc is a maximum length of an excerpt, after which it is truncated.
+	Marks      [3][]byte                             // Marks: 0 = truncate; 1 = empty; 2 = blank.
+	Replace    [][2][]byte                           // Replace ia a pairs of byte slices to replace in the message excerpt.
+	Sampler    Sampler                               // Sampler decides whether to write the message, all messages are written if nil.
+	Redactor   Redactor                              // Redactor masks sensitive data in the key-values and the message.
+	Transforms []Transform                           // Transforms is a regular expression and function replacements in the message excerpt and/or the original message.
+	Unit       int                                   // Unit is a unit of the Trunc length: bytes, runes, grapheme clusters or display width.
+	Words      bool                                  // Words truncates excerpt at the last word boundary before the limit.
+	Strategy   int                                   // Strategy is an excerpt strategy: truncate, first line, first sentence or head and tail.
+	UTF8       int                                   // UTF8 is a policy of the invalid UTF-8 in the message: keep, replace, escape or base64.
+	UTF8Keys   [2]string                             // UTF8Keys: 0 = base64 of the message, "message_base64" if empty; 1 = invalid UTF-8 flag, "invalid_utf8" if empty.
+	Neutralize bool                                  // Neutralize escapes terminal escape and bidirectional control characters in the message.
+	Harden     bool                                  // Harden guarantees exactly one record per line and escapes CR/LF in all string values.
+	HMAC       []byte                                // HMAC is a secret of the per-record HMAC-SHA256 field, no field if nil.
+	HMACKey    string                                // HMACKey is a key of the per-record HMAC field, "hmac" if empty.
+	Audit      *Audit                                // Audit is a tamper-evident hash chain of the records, off if nil.
+	Groups     []string                              // Groups is a namespace of the key-values of the tee'd loggers.
+	Flatten    bool                                  // Flatten flattens groups to the dotted keys, for example "http.method".
+	Separator  string                                // Separator joins keys of the flattened groups, "." if empty.
+	Prefix     string                                // Prefix is a prefix of the flattened groups keys, for example "_" of the GELF additional fields.
+	Stack      *Stack                                // Stack attaches stack trace to the records of the severity level at or above the threshold.
+	LevelKey   encoding.TextMarshaler                // LevelKey is a key of the severity level of the context functions, the access log and the standard logger, "level" if nil.
+	LevelNum   bool                                  // LevelNum encodes severity level of the LevelKey as a syslog number, for example GELF level.
+	Trace      *Trace                                // Trace is a names of the OpenTelemetry trace fields of the Context, "trace_id", "span_id" and "trace_flags" if nil.
+
+	ctx context.Context // ctx is a context of the active OpenTelemetry span of the Context.
 }
 
 type Logger interface {
@@ -62,6 +92,22 @@ type Logger interface {
 	Tee(...pfmt.KV) Logger
 	// Close puts the logger into the sync pool.
 	Close() error
+	// Sync flushes the outputs of the logger and the tee'd loggers.
+	Sync() error
+	// Flush flushes the outputs of the logger and the tee'd loggers
+	// within the context.
+	Flush(context.Context) error
+}
+
+// Syncer is an output which may be synced, for example *os.File.
+type Syncer interface {
+	Sync() error
+}
+
+// Flusher is an output which may be flushed within the context,
+// for example asynchronous writer.
+type Flusher interface {
+	Flush(context.Context) error
 }
 
 // KeyValuer provides key-values slice.
@@ -93,11 +139,15 @@ func (l *Log) Encode(kv ...pfmt.KV) []byte {
 	l0 := l.Tee(kv...)
 
 	for _, x := range l0.(KeyValuer).KeyValues() {
-		p, err := x.MarshalText()
+		err := l.add(m, x)
 		if err != nil {
 			return nil
 		}
-		m[string(p)] = x
+	}
+
+	err := l.redact(m)
+	if err != nil {
+		return nil
 	}
 
 	p, err := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(m)
@@ -112,6 +162,30 @@ type Leveler interface {
 	Level() string
 }
 
+// severity returns the syslog severity level number (0 emergency ... 7 debug)
+// of the level which is either a number or a name like "err" or "warning".
+func severity(level string) (int, bool) {
+	switch strings.ToLower(level) {
+	case "0", "emerg", "emergency", "panic":
+		return 0, true
+	case "1", "alert":
+		return 1, true
+	case "2", "crit", "critical", "fatal":
+		return 2, true
+	case "3", "err", "error":
+		return 3, true
+	case "4", "warn", "warning":
+		return 4, true
+	case "5", "notice":
+		return 5, true
+	case "6", "info", "informational":
+		return 6, true
+	case "7", "debug", "trace":
+		return 7, true
+	}
+	return 0, false
+}
+
 var logPool = sync.Pool{New: func() interface{} { return new(Log) }}
 
 // Tee returns copy of the logger with additional key-values.
@@ -125,13 +199,35 @@ func (l *Log) Tee(kv ...pfmt.KV) Logger {
 	l0 := logPool.Get().(*Log)
 	l0.Output = l.Output
 	l0.Flag = l.Flag
-	l0.KV = append(l0.KV[:0], append(l.KV, kv...)...)
+	l0.KV = append(l0.KV[:0], append(l.KV, l.group(kv)...)...)
 	l0.Level = l.Level
 	l0.Keys = l.Keys
 	l0.Key = l.Key
 	l0.Trunc = l.Trunc
 	l0.Marks = l.Marks
 	l0.Replace = append(l0.Replace[:0], l.Replace...)
+	l0.Sampler = l.Sampler
+	l0.Redactor = l.Redactor
+	l0.Transforms = append(l0.Transforms[:0], l.Transforms...)
+	l0.Unit = l.Unit
+	l0.Words = l.Words
+	l0.Strategy = l.Strategy
+	l0.UTF8 = l.UTF8
+	l0.UTF8Keys = l.UTF8Keys
+	l0.Neutralize = l.Neutralize
+	l0.Harden = l.Harden
+	l0.HMAC = l.HMAC
+	l0.HMACKey = l.HMACKey
+	l0.Audit = l.Audit
+	l0.Groups = append(l0.Groups[:0], l.Groups...)
+	l0.Flatten = l.Flatten
+	l0.Separator = l.Separator
+	l0.Prefix = l.Prefix
+	l0.Stack = l.Stack
+	l0.LevelKey = l.LevelKey
+	l0.LevelNum = l.LevelNum
+	l0.Trace = l.Trace
+	l0.ctx = l.ctx
 
 	if l0.Level != nil && len(kv) > 0 {
 		s, ok := kv[0].(Leveler)
@@ -152,6 +248,108 @@ func (l *Log) Close() error {
 	return nil
 }
 
+// Sync flushes the outputs of the log and the tee'd logs.
+func (l *Log) Sync() error {
+	return l.Flush(context.Background())
+}
+
+// Flush flushes the output of the log and the outputs returned by the Level function
+// for the known severity levels, which implement the Flusher or the Syncer interfaces.
+func (l *Log) Flush(ctx context.Context) error {
+	err := flush(ctx, l.Output)
+	if l.Level == nil {
+		return err
+	}
+
+	flushed := []io.Writer{l.Output}
+	for _, level := range levels {
+		out := l.Level(level)
+		if out == nil || seen(flushed, out) {
+			continue
+		}
+		flushed = append(flushed, out)
+
+		e := flush(ctx, out)
+		if err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// levels are the known severity levels of the Level function.
+var levels = []string{
+	"0", "emerg", "emergency", "panic",
+	"1", "alert",
+	"2", "crit", "critical", "fatal",
+	"3", "err", "error",
+	"4", "warn", "warning",
+	"5", "notice",
+	"6", "info", "informational",
+	"7", "debug", "trace",
+}
+
+// seen reports whether the output is one of the flushed outputs,
+// outputs of the non-comparable types are never seen.
+func seen(flushed []io.Writer, w io.Writer) bool {
+	if !reflect.TypeOf(w).Comparable() {
+		return false
+	}
+	for _, x := range flushed {
+		if x == w {
+			return true
+		}
+	}
+	return false
+}
+
+// flush flushes output if output implements the Flusher or the Syncer interface.
+func flush(ctx context.Context, output io.Writer) error {
+	switch w := output.(type) {
+	case Flusher:
+		return w.Flush(ctx)
+	case Syncer:
+		err := w.Sync()
+		// Standard streams are not syncable if they are terminals or pipes.
+		if errors.Is(err, syscall.EINVAL) || errors.Is(err, syscall.ENOTSUP) {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// exitTimeout is a maximum time of the flush before exit.
+const exitTimeout = 5 * time.Second
+
+// Recover writes panic to the logger, flushes the logger and panics again.
+// Intends to be deferred, for example in the main function:
+//
+//	defer plog.Recover(l)
+func Recover(l Logger) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	_, _ = fmt.Fprintf(l, "panic: %v", r)
+
+	ctx, cancel := context.WithTimeout(context.Background(), exitTimeout)
+	defer cancel()
+	_ = l.Flush(ctx)
+
+	panic(r)
+}
+
+// Exit flushes the logger and exits with the status code.
+func Exit(l Logger, code int) {
+	ctx, cancel := context.WithTimeout(context.Background(), exitTimeout)
+	_ = l.Flush(ctx)
+	cancel()
+
+	os.Exit(code)
+}
+
 // Write implements io.Writer. Do nothing if log does not have output.
 func (l *Log) Write(src []byte) (int, error) {
 	if l.Output == nil {
@@ -160,7 +358,7 @@ func (l *Log) Write(src []byte) (int, error) {
 	return l.write(src)
 }
 
-func (l Log) write(src []byte) (int, error) {
+func (l *Log) write(src []byte) (int, error) {
 	dst := *mapPool.Get().(*map[string]json.Marshaler)
 	for k := range dst {
 		delete(dst, k)
@@ -176,32 +374,139 @@ func (l Log) write(src []byte) (int, error) {
 		return 0, err
 	}
 
-	p, err := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(dst)
+	err = l.redact(dst)
 	if err != nil {
 		return 0, err
 	}
 
-	num, err := l.Output.Write(p)
+	if l.Sampler != nil {
+		msg, err := l.message(dst)
+		if err != nil {
+			return 0, err
+		}
+
+		ok, kv := l.Sampler.Sample(l.level(), msg, l.KV)
+		if !ok {
+			return len(src), nil
+		}
+
+		if kv != nil {
+			k, err := kv.MarshalText()
+			if err != nil {
+				return 0, err
+			}
+			dst[string(k)] = kv
+		}
+	}
+
+	// Captures stack trace after the sampler,
+	// so the dropped records do not pay for it.
+	if l.Stack != nil {
+		l.Stack.attach(dst, l.level())
+	}
+
+	if l.Trace != nil && l.Trace.Event != "" && l.ctx != nil {
+		msg, err := l.message(dst)
+		if err != nil {
+			return 0, err
+		}
+		l.Trace.event(l.ctx, l.level(), msg)
+	}
+
+	p, err := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(dst)
 	if err != nil {
-		return num, err
+		return 0, err
+	}
+
+	if l.Harden {
+		p, err = harden(p)
+		if err != nil {
+			return 0, err
+		}
 	}
 
-	n, err := l.Output.Write([]byte{'\n'})
-	num += n
-	return num, err
+	// Holds the chain until the record is written,
+	// so the order of the records matches the order of the hashes.
+	if l.Audit != nil {
+		l.Audit.mu.Lock()
+		defer l.Audit.mu.Unlock()
+		p = l.Audit.chain(p)
+	}
+
+	if l.HMAC != nil {
+		p = l.sign(p)
+	}
+
+	// Writes the record with the trailing new line at once,
+	// so the datagram/rotating outputs receive exactly one record per write.
+	return l.Output.Write(append(p, '\n'))
+}
+
+// levelKV returns key-value of the severity level of the logger.
+func levelKV(l Logger, level string) pfmt.KV {
+	var key encoding.TextMarshaler = pfmt.String("level")
+	var num bool
+
+	if l, ok := l.(*Log); ok {
+		if l.LevelKey != nil {
+			key = l.LevelKey
+		}
+		num = l.LevelNum
+	}
+
+	if sev, ok := severity(level); ok && num {
+		return kvl{K: key, V: pfmt.Int(sev), S: pfmt.String(level)}
+	}
+	return kvl{K: key, V: pfmt.String(level), S: pfmt.String(level)}
+}
+
+// level returns the severity level of the last key-value pair
+// which implements the Leveler interface.
+func (l *Log) level() string {
+	for i := len(l.KV) - 1; i >= 0; i-- {
+		if s, ok := l.KV[i].(Leveler); ok {
+			return s.Level()
+		}
+	}
+	return ""
+}
+
+// message returns the message excerpt of the record
+// or the original message if excerpt is absent.
+func (l *Log) message(dst map[string]json.Marshaler) ([]byte, error) {
+	for _, k := range []encoding.TextMarshaler{l.Keys[Excerpt], l.Keys[Original]} {
+		if k == nil {
+			continue
+		}
+		p, err := k.MarshalText()
+		if err != nil {
+			return nil, err
+		}
+		if v, ok := dst[string(p)]; ok && v != nil {
+			return v.MarshalJSON()
+		}
+	}
+	return nil, nil
 }
 
 var asciiSpace = [256]uint8{'\t': 1, '\n': 1, '\v': 1, '\f': 1, '\r': 1, ' ': 1}
 
 var excerptPool = sync.Pool{New: func() interface{} { return new([]byte) }}
 
-func (l Log) excerpt(dst map[string]json.Marshaler, excerpt []byte, src ...byte) error {
+func (l *Log) excerpt(dst map[string]json.Marshaler, excerpt []byte, src ...byte) error {
 	for _, kv := range l.KV {
-		p, err := kv.MarshalText()
+		err := l.add(dst, kv)
 		if err != nil {
 			return err
 		}
-		dst[string(p)] = kv
+	}
+
+	if len(src) != 0 && (l.UTF8 != UTF8Keep || l.Neutralize) {
+		p, invalid := l.sanitize(src)
+		if invalid && l.UTF8 == UTF8Base64 {
+			l.flagInvalid(dst, src)
+		}
+		src = p
 	}
 
 	var tail, file int
@@ -220,6 +525,22 @@ func (l Log) excerpt(dst map[string]json.Marshaler, excerpt []byte, src ...byte)
 		}
 	}
 
+	text := src[tail:]
+
+	if len(l.Transforms) != 0 && tail != len(src) {
+		text = l.transform(text, TransformExcerpt)
+
+		if msg := l.transform(src[tail:], TransformOriginal); !bytes.Equal(msg, src[tail:]) {
+			src = append(src[:tail:tail], msg...)
+		}
+	}
+
+	var rest []byte
+
+	if l.Strategy != ExcerptTruncate && tail != len(src) {
+		text, rest = l.strategy(text)
+	}
+
 	var originalKey string
 
 	if l.Keys[Original] == nil {
@@ -249,15 +570,21 @@ func (l Log) excerpt(dst map[string]json.Marshaler, excerpt []byte, src ...byte)
 			excerpt = append(excerpt, l.Marks[Empty]...)
 
 		} else if tail != len(src) {
-			n := len(src) + len(l.Marks[Trunc])
+			n := len(text) + len(l.Marks[Trunc])
 			for _, m := range l.Marks {
 				if n < len(m) {
 					n = len(m)
 				}
 			}
 
+			// Head and tail excerpt is of the Trunc length already.
+			trunc := l.Trunc
+			if l.Strategy == ExcerptHeadTail {
+				trunc = 0
+			}
+
 			excerpt = append(excerpt, make([]byte, n)...)
-			n, err := l.Truncate(excerpt, src[tail:])
+			n, err := l.truncate(excerpt, text, trunc)
 			if err != nil {
 				return err
 			}
@@ -280,28 +607,32 @@ func (l Log) excerpt(dst map[string]json.Marshaler, excerpt []byte, src ...byte)
 
 	if bytes.Equal(src, excerpt) && src != nil {
 		if l.Key == Excerpt {
-			dst[excerptKey] = pfmt.Bytes(src)
+			dst[excerptKey] = l.bytes(src)
 
 		} else {
 			if dst[originalKey] == nil {
-				dst[originalKey] = pfmt.Bytes(src)
+				dst[originalKey] = l.bytes(src)
 			} else if len(src) != 0 {
-				dst[trailKey] = pfmt.Bytes(src)
+				dst[trailKey] = l.bytes(src)
 			}
 		}
 
 	} else if !bytes.Equal(src, excerpt) {
 		if dst[originalKey] == nil {
-			dst[originalKey] = pfmt.Bytes(src)
+			dst[originalKey] = l.bytes(src)
 		} else if dst[originalKey] != nil && len(src) != 0 {
-			dst[trailKey] = pfmt.Bytes(src)
+			dst[trailKey] = l.bytes(src)
 		}
 
 		if dst[excerptKey] == nil && len(excerpt) != 0 {
-			dst[excerptKey] = pfmt.Bytes(excerpt)
+			dst[excerptKey] = l.bytes(excerpt)
 		}
 	}
 
+	if len(rest) != 0 && l.Keys[Trail] != nil && dst[trailKey] == nil {
+		dst[trailKey] = l.bytes(rest)
+	}
+
 	var fileKey string
 
 	if l.Keys[File] == nil {
@@ -315,7 +646,7 @@ func (l Log) excerpt(dst map[string]json.Marshaler, excerpt []byte, src ...byte)
 	}
 
 	if file != 0 {
-		dst[fileKey] = pfmt.Bytes(src[:file])
+		dst[fileKey] = l.bytes(src[:file])
 	}
 
 	return nil
@@ -342,9 +673,19 @@ func lastIndexFunc(s []byte, f func(r rune) bool, truth bool) int {
 // Truncate writes excerpt of the src to the dst and returns number of the written bytes
 // and error if occurre.
 func (l Log) Truncate(dst, src []byte) (int, error) {
-	var start, end int
+	return l.truncate(dst, src, l.Trunc)
+}
+
+// truncate writes excerpt of the src of the trunc length to the dst.
+func (l *Log) truncate(dst, src []byte, trunc int) (int, error) {
+	var start, end, length int
 	begin := true
 
+	var bounds []int
+	if l.Unit == TruncGraphemes || l.Unit == TruncWidth {
+		bounds = graphemes(src)
+	}
+
 	for {
 		r, n := utf8.DecodeRune(src[end:])
 		if n == 0 {
@@ -373,15 +714,32 @@ func (l Log) Truncate(dst, src []byte) (int, error) {
 			}
 		}
 
-		if end-start >= len(src) || (l.Trunc > 0 && end-start >= l.Trunc) {
+		if end-start >= len(src) || (trunc > 0 && length >= trunc) {
+			break
+		}
+
+		size, width := l.unit(src, end, n, bounds)
+		if l.Unit == TruncWidth && trunc > 0 && length+width > trunc {
 			break
 		}
 
-		end += n
+		end += size
+		length += width
 	}
 
 	truncate := end-start < len(src[start:])
 
+	// Cuts at the last word boundary before the limit.
+	if truncate && l.Words && end < len(src) {
+		r, _ := utf8.DecodeRune(src[end:])
+		if !unicode.IsSpace(r) {
+			i := lastIndexFunc(src[start:end], unicode.IsSpace, true)
+			if i > 0 {
+				end = start + i
+			}
+		}
+	}
+
 	// Rids of off all trailing white space,
 	// as defined by Unicode.
 	// Look for the first ASCII non-space byte from the end.
@@ -456,6 +814,14 @@ func GELF() *Log {
 		Key:     Excerpt,
 		Marks:   [3][]byte{[]byte("…"), []byte("_EMPTY_"), []byte("_BLANK_")},
 		Replace: [][2][]byte{[2][]byte{[]byte("\n"), []byte(" ")}},
+		// GELF forbids nested objects, so groups and errors
+		// are flattened to the additional fields like "_error_message".
+		Flatten:   true,
+		Separator: "_",
+		Prefix:    "_",
+		LevelKey:  pfmt.String("level"),
+		LevelNum:  true,
+		Trace:     TraceGELF(),
 	}
 }
 
@@ -532,6 +898,61 @@ func WithBlankLabel(label []byte) Option {
 	return func(l *Log) { l.Marks[2] = label }
 }
 
+// WithTruncateUnit sets a unit of the maximum length of an excerpt.
+func WithTruncateUnit(unit int) Option {
+	return func(l *Log) { l.Unit = unit }
+}
+
+// WithTruncateWords truncates excerpt at the last word boundary.
+func WithTruncateWords() Option {
+	return func(l *Log) { l.Words = true }
+}
+
+// WithExcerptStrategy sets an excerpt strategy.
+func WithExcerptStrategy(strategy int) Option {
+	return func(l *Log) { l.Strategy = strategy }
+}
+
+// WithInvalidUTF8 sets a policy of the invalid UTF-8 in the message.
+func WithInvalidUTF8(policy int) Option {
+	return func(l *Log) { l.UTF8 = policy }
+}
+
+// WithNeutralize escapes terminal escape and bidirectional control characters in the message.
+func WithNeutralize() Option {
+	return func(l *Log) { l.Neutralize = true }
+}
+
+// WithHarden guarantees exactly one record per line and escapes CR/LF in all string values.
+func WithHarden() Option {
+	return func(l *Log) { l.Harden = true }
+}
+
+// WithHMAC adds per-record HMAC-SHA256 field.
+func WithHMAC(secret []byte) Option {
+	return func(l *Log) { l.HMAC = secret }
+}
+
+// WithAudit chains records by the tamper-evident hash chain.
+func WithAudit(audit *Audit) Option {
+	return func(l *Log) { l.Audit = audit }
+}
+
+// WithFlatten flattens groups to the dotted keys.
+func WithFlatten() Option {
+	return func(l *Log) { l.Flatten = true }
+}
+
+// WithStack attaches stack trace to the records of the severity level at or above the threshold.
+func WithStack(stack *Stack) Option {
+	return func(l *Log) { l.Stack = stack }
+}
+
+// WithTrace sets names of the OpenTelemetry trace fields and the span events threshold.
+func WithTrace(trace *Trace) Option {
+	return func(l *Log) { l.Trace = trace }
+}
+
 // WithReplace add find and replace pair.
 func WithReplace(find, replace []byte) Option {
 	return func(l *Log) { l.Replace = append(l.Replace, [2][]byte{find, replace}) }
@@ -542,4 +963,19 @@ func WithoutReplace() Option {
 	return func(l *Log) { l.Replace = nil }
 }
 
+// WithSampler sets a sampler decides whether to write the message.
+func WithSampler(sampler Sampler) Option {
+	return func(l *Log) { l.Sampler = sampler }
+}
+
+// WithRedactor sets a redactor masks sensitive data.
+func WithRedactor(redactor Redactor) Option {
+	return func(l *Log) { l.Redactor = redactor }
+}
+
+// WithTransform adds a regular expression or function replacement.
+func WithTransform(t Transform) Option {
+	return func(l *Log) { l.Transforms = append(l.Transforms, t) }
+}
+
 // Replace [][2][]byte                           // Replace ia a pairs of byte slices to replace in the message excerpt.
@@ -6,6 +6,7 @@ package plog_test
 
 import (
 	"bytes"
+	"context"
 	"encoding"
 	"fmt"
 	"io"
@@ -1652,6 +1653,61 @@ func TestTruncate(t *testing.T) {
 			line:  line(),
 			input: []byte("foobar foobar"),
 			want:  []byte(" "),
+		}, {
+			name: "truncate cyrillic by runes",
+			log: &plog.Log{
+				Output: &bytes.Buffer{},
+				Trunc:  6,
+				Unit:   plog.TruncRunes,
+				Marks:  [3][]byte{[]byte("…")},
+			},
+			line:  line(),
+			input: []byte("Привет, Мир!"),
+			want:  []byte("Привет…"),
+		}, {
+			name: "truncate by grapheme clusters does not split emoji zwj sequence",
+			log: &plog.Log{
+				Output: &bytes.Buffer{},
+				Trunc:  3,
+				Unit:   plog.TruncGraphemes,
+				Marks:  [3][]byte{[]byte("…")},
+			},
+			line:  line(),
+			input: []byte("hi👩‍👩‍👧 family"),
+			want:  []byte("hi👩‍👩‍👧…"),
+		}, {
+			name: "truncate by display width of the east asian wide characters",
+			log: &plog.Log{
+				Output: &bytes.Buffer{},
+				Trunc:  5,
+				Unit:   plog.TruncWidth,
+				Marks:  [3][]byte{[]byte("…")},
+			},
+			line:  line(),
+			input: []byte("日本語のテキスト"),
+			want:  []byte("日本…"),
+		}, {
+			name: "truncate at the last word boundary",
+			log: &plog.Log{
+				Output: &bytes.Buffer{},
+				Trunc:  10,
+				Words:  true,
+				Marks:  [3][]byte{[]byte("…")},
+			},
+			line:  line(),
+			input: []byte("Hello, wonderful World!"),
+			want:  []byte("Hello,…"),
+		}, {
+			name: "truncate at the word boundary at the limit",
+			log: &plog.Log{
+				Output: &bytes.Buffer{},
+				Trunc:  6,
+				Words:  true,
+				Marks:  [3][]byte{[]byte("…")},
+			},
+			line:  line(),
+			input: []byte("Hello, World!"),
+			want:  []byte("Hello,…"),
 		},
 	}
 
@@ -1938,3 +1994,75 @@ func TestEncode(t *testing.T) {
 		`{"foo":"bar","greeting":"Hello,\nWorld!"}`,
 	)
 }
+
+// flushWriter counts flushes.
+type flushWriter struct {
+	bytes.Buffer
+	flushes int
+}
+
+func (w *flushWriter) Flush(ctx context.Context) error {
+	w.flushes++
+	return ctx.Err()
+}
+
+func TestFlush(t *testing.T) {
+	out, errs := &flushWriter{}, &flushWriter{}
+
+	l := &plog.Log{
+		Output: out,
+		Level: func(level string) io.Writer {
+			if level == "3" {
+				return errs
+			}
+			return nil
+		},
+	}
+
+	l0 := l.Tee(plog.StringLevel("level", "3"))
+	defer l0.Close()
+
+	_, err := l0.Write([]byte("Hello, World!"))
+	if err != nil {
+		t.Fatalf("unwant write error: %s", err)
+	}
+
+	err = l.Sync()
+	if err != nil {
+		t.Fatalf("unwant sync error: %s", err)
+	}
+
+	if out.flushes != 1 || errs.flushes != 1 {
+		t.Errorf("want outputs of the log and the tee'd log flushed once, got: %d, %d", out.flushes, errs.flushes)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = l0.Flush(ctx)
+	if err != context.Canceled {
+		t.Errorf("want canceled error, got: %v", err)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	out := &flushWriter{}
+	l := &plog.Log{Output: out, Keys: [4]encoding.TextMarshaler{pfmt.String("message")}}
+
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("want repanic, got: %v", r)
+			}
+		}()
+		defer plog.Recover(l)
+		panic("boom")
+	}()
+
+	ja := jsonassert.New(testprinter{t: t, link: line()})
+	ja.Assertf(out.String(), `{"message":"panic: boom"}`)
+
+	if out.flushes != 1 {
+		t.Errorf("want output flushed once, got: %d", out.flushes)
+	}
+}
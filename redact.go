@@ -0,0 +1,299 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plog
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"path"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/pfmt/pfmt"
+)
+
+// Redactor masks sensitive data.
+type Redactor interface {
+	// Redact receives key and JSON encoded value,
+	// returns JSON encoded value with masked sensitive data
+	// and reports whether value was changed.
+	Redact(key string, value []byte) ([]byte, bool)
+}
+
+// Masking strategies of the redactor.
+const (
+	MaskFull  = iota // MaskFull replaces sensitive data by the label.
+	MaskLast4        // MaskLast4 keeps last 4 characters of the sensitive data.
+	MaskHMAC         // MaskHMAC replaces sensitive data by the HMAC-SHA256 hash for correlation.
+)
+
+// Detector detects sensitive data in the value.
+type Detector struct {
+	Name   string                  // Name is a name of the detector.
+	Regexp *regexp.Regexp          // Regexp matches sensitive data, first submatch is masked if present.
+	Valid  func(match []byte) bool // Valid reports whether match is sensitive data, for example Luhn check, all matches are sensitive if nil.
+}
+
+// Detectors is a built-in detectors of the sensitive data.
+var Detectors = []Detector{
+	{Name: "jwt", Regexp: regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`)},
+	{Name: "bearer", Regexp: regexp.MustCompile(`(?i)\bbearer\s+([A-Za-z0-9\-._~+/]+=*)`)},
+	{Name: "aws", Regexp: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{Name: "pan", Regexp: regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`), Valid: luhn},
+	{Name: "email", Regexp: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)},
+}
+
+// Redact is a redactor which masks whole values of the keys matched by name
+// and sensitive data detected inside string values,
+// including original message, message excerpt
+// and keys and strings nested in the arrays and objects.
+type Redact struct {
+	Keys      []string         // Keys is a case-insensitive glob patterns of the keys, for example "*password*".
+	Regexps   []*regexp.Regexp // Regexps is a regular expressions of the keys.
+	Detectors []Detector       // Detectors is a detectors of the sensitive data in the values, for example plog.Detectors.
+	Mask      int              // Mask is a masking strategy.
+	Secret    []byte           // Secret is a key of the HMAC masking strategy.
+	Label     string           // Label replaces sensitive data by the full masking strategy, "[REDACTED]" if empty.
+}
+
+// Redact implements Redactor interface.
+func (r *Redact) Redact(key string, value []byte) ([]byte, bool) {
+	if r.matchKey(key) {
+		s := string(value)
+		var str string
+		if jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(value, &str) == nil {
+			s = str
+		}
+		p, _ := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(r.mask(s))
+		return p, true
+	}
+
+	if len(value) != 0 && (value[0] == '[' || value[0] == '{') {
+		return r.redactJSON(value)
+	}
+
+	if len(r.Detectors) == 0 || len(value) == 0 || value[0] != '"' {
+		return value, false
+	}
+
+	var s string
+	err := jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(value, &s)
+	if err != nil {
+		return value, false
+	}
+
+	s, ok := r.RedactString(s)
+	if !ok {
+		return value, false
+	}
+
+	p, err := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(s)
+	if err != nil {
+		return value, false
+	}
+	return p, true
+}
+
+// redactJSON is a JSON config which keeps numbers of the arrays and objects as is.
+var redactJSON = jsoniter.Config{EscapeHTML: true, SortMapKeys: true, ValidateJsonRawMessage: true, UseNumber: true}.Froze()
+
+// redactJSON masks values of the matched keys and sensitive data
+// of the strings inside the JSON array or object.
+func (r *Redact) redactJSON(value []byte) ([]byte, bool) {
+	var v interface{}
+	err := redactJSON.Unmarshal(value, &v)
+	if err != nil {
+		return value, false
+	}
+
+	v, ok := r.walk(v)
+	if !ok {
+		return value, false
+	}
+
+	p, err := redactJSON.Marshal(v)
+	if err != nil {
+		return value, false
+	}
+	return p, true
+}
+
+// walk masks sensitive data of the decoded JSON value recursively
+// and reports whether value was changed.
+func (r *Redact) walk(v interface{}) (interface{}, bool) {
+	var changed bool
+
+	switch x := v.(type) {
+	case string:
+		if len(r.Detectors) == 0 {
+			return x, false
+		}
+		return r.RedactString(x)
+
+	case []interface{}:
+		for i := range x {
+			y, ok := r.walk(x[i])
+			if ok {
+				x[i] = y
+				changed = true
+			}
+		}
+
+	case map[string]interface{}:
+		for k, y := range x {
+			if r.matchKey(k) {
+				s, ok := y.(string)
+				if !ok {
+					p, _ := redactJSON.Marshal(y)
+					s = string(p)
+				}
+				x[k] = r.mask(s)
+				changed = true
+				continue
+			}
+
+			y, ok := r.walk(y)
+			if ok {
+				x[k] = y
+				changed = true
+			}
+		}
+	}
+
+	return v, changed
+}
+
+// RedactString masks sensitive data detected inside the string
+// and reports whether string was changed.
+func (r *Redact) RedactString(s string) (string, bool) {
+	var changed bool
+
+	for _, d := range r.Detectors {
+		s = d.Regexp.ReplaceAllStringFunc(s, func(m string) string {
+			if d.Valid != nil && !d.Valid([]byte(m)) {
+				return m
+			}
+
+			changed = true
+
+			sub := d.Regexp.FindStringSubmatchIndex(m)
+			if len(sub) >= 4 && sub[2] >= 0 {
+				return m[:sub[2]] + r.mask(m[sub[2]:sub[3]]) + m[sub[3]:]
+			}
+			return r.mask(m)
+		})
+	}
+
+	return s, changed
+}
+
+func (r *Redact) matchKey(key string) bool {
+	k := strings.ToLower(key)
+	for _, pattern := range r.Keys {
+		ok, err := path.Match(strings.ToLower(pattern), k)
+		if err == nil && ok {
+			return true
+		}
+	}
+	for _, re := range r.Regexps {
+		if re.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Redact) mask(s string) string {
+	switch r.Mask {
+	case MaskLast4:
+		if utf8.RuneCountInString(s) <= 4 {
+			return strings.Repeat("*", utf8.RuneCountInString(s))
+		}
+		i := len(s)
+		for n := 0; n < 4; n++ {
+			_, size := utf8.DecodeLastRuneInString(s[:i])
+			i -= size
+		}
+		return "****" + s[i:]
+
+	case MaskHMAC:
+		h := hmac.New(sha256.New, r.Secret)
+		h.Write([]byte(s))
+		return "hmac:" + hex.EncodeToString(h.Sum(nil))[:16]
+	}
+
+	if r.Label == "" {
+		return "[REDACTED]"
+	}
+	return r.Label
+}
+
+// luhn reports whether digits of the match pass the Luhn check.
+func luhn(match []byte) bool {
+	var sum, n int
+	for i := len(match) - 1; i >= 0; i-- {
+		c := match[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && n <= 19 && sum%10 == 0
+}
+
+// redact masks sensitive data in the values of the record.
+func (l *Log) redact(dst map[string]json.Marshaler) error {
+	if l.Redactor == nil {
+		return nil
+	}
+	return redactObject(l.Redactor, dst)
+}
+
+// redactObject masks sensitive data in the values of the object
+// and the nested groups.
+func redactObject(r Redactor, dst map[string]json.Marshaler) error {
+	for k, v := range dst {
+		if v == nil {
+			continue
+		}
+
+		if g, ok := v.(kvg); ok {
+			m, err := g.object()
+			if err != nil {
+				return err
+			}
+			err = redactObject(r, m)
+			if err != nil {
+				return err
+			}
+			dst[k] = m
+			continue
+		}
+
+		p, err := v.MarshalJSON()
+		if err != nil {
+			return err
+		}
+
+		p, ok := r.Redact(k, p)
+		if ok {
+			dst[k] = pfmt.Raw(p)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,293 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plog
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// rotateLayout is a time layout of the rotated file suffix.
+const rotateLayout = "2006-01-02T15-04-05.000000000"
+
+// RotatingFile is a file writer which rotates file by size, by interval or both.
+// Each record writes at once, so record is never split between files.
+type RotatingFile struct {
+	Path     string        // Path is a path of the current log file.
+	MaxSize  int64         // MaxSize is a maximum size of the file in bytes, size rotation is off if zero.
+	Interval time.Duration // Interval is a maximum age of the file, time rotation is off if zero.
+	Backups  int           // Backups is a number of the rotated files to keep, keeps all if zero.
+	Compress bool          // Compress gzips rotated files in background.
+	Perm     os.FileMode   // Perm is a file permissions, 0644 if zero.
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	opened time.Time
+	wg     sync.WaitGroup
+	sig    chan os.Signal
+}
+
+// Write implements io.Writer.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		err := f.open()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if f.size > 0 && ((f.MaxSize > 0 && f.size+int64(len(p)) > f.MaxSize) ||
+		(f.Interval > 0 && time.Since(f.opened) >= f.Interval)) {
+		err := f.rotate()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Rotate renames the current file to the backup and opens the new one.
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		err := f.open()
+		if err != nil {
+			return err
+		}
+	}
+
+	return f.rotate()
+}
+
+// Reopen closes and opens the file by the same path,
+// intends for the external rotation, for example by logrotate.
+func (f *RotatingFile) Reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file != nil {
+		err := f.file.Close()
+		f.file = nil
+		if err != nil {
+			return err
+		}
+	}
+
+	return f.open()
+}
+
+// ReopenOnSignal reopens the file on each signal, SIGHUP if signals are not provided.
+func (f *RotatingFile) ReopenOnSignal(sig ...os.Signal) {
+	if len(sig) == 0 {
+		sig = []os.Signal{syscall.SIGHUP}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.sig != nil {
+		signal.Stop(f.sig)
+		close(f.sig)
+	}
+
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, sig...)
+	f.sig = c
+
+	go func() {
+		for range c {
+			_ = f.Reopen()
+		}
+	}()
+}
+
+// Sync commits the current file to the stable storage.
+func (f *RotatingFile) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+	return f.file.Sync()
+}
+
+// Close closes the file and waits for the background compression.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+
+	if f.sig != nil {
+		signal.Stop(f.sig)
+		close(f.sig)
+		f.sig = nil
+	}
+
+	var err error
+	if f.file != nil {
+		err = f.file.Close()
+		f.file = nil
+	}
+
+	f.mu.Unlock()
+
+	f.wg.Wait()
+	return err
+}
+
+func (f *RotatingFile) open() error {
+	perm := f.Perm
+	if perm == 0 {
+		perm = 0o644
+	}
+
+	err := os.MkdirAll(filepath.Dir(f.Path), 0o755)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(f.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, perm)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.opened = time.Now()
+	return nil
+}
+
+func (f *RotatingFile) rotate() error {
+	err := f.file.Close()
+	f.file = nil
+	if err != nil {
+		return err
+	}
+
+	backup := f.Path + "." + time.Now().Format(rotateLayout)
+
+	err = os.Rename(f.Path, backup)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	err = f.open()
+	if err != nil {
+		return err
+	}
+
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		if f.Compress {
+			_ = compress(backup)
+		}
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		_ = f.cleanup()
+	}()
+
+	return nil
+}
+
+// cleanup removes the oldest backups which exceed the number of the backups.
+func (f *RotatingFile) cleanup() error {
+	if f.Backups <= 0 {
+		return nil
+	}
+
+	matches, err := filepath.Glob(f.Path + ".*")
+	if err != nil {
+		return err
+	}
+
+	var backups []string
+	for _, m := range matches {
+		suffix := strings.TrimSuffix(strings.TrimPrefix(m, f.Path+"."), ".gz")
+		_, err := time.Parse(rotateLayout, suffix)
+		if err == nil {
+			backups = append(backups, m)
+		}
+	}
+
+	// Backup names sort by time because of the time layout of the suffix.
+	sort.Strings(backups)
+
+	for len(backups) > f.Backups {
+		err = os.Remove(backups[0])
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		backups = backups[1:]
+	}
+
+	return nil
+}
+
+// compress gzips file with the permissions of the file and removes the uncompressed one.
+func compress(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	fi, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	dst, err := os.OpenFile(path+".gz.tmp", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, fi.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	z := gzip.NewWriter(dst)
+
+	_, err = io.Copy(z, src)
+	if err != nil {
+		return err
+	}
+
+	err = z.Close()
+	if err != nil {
+		return err
+	}
+
+	err = dst.Close()
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(path+".gz.tmp", path+".gz")
+	if err != nil {
+		return err
+	}
+
+	return os.Remove(path)
+}
@@ -0,0 +1,150 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plog_test
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pfmt/pfmt"
+	"github.com/pfmt/plog"
+)
+
+func TestRotatingFileSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plog.log")
+
+	f := &plog.RotatingFile{Path: path, MaxSize: 64, Backups: 2}
+
+	l := &plog.Log{Output: f, Keys: [4]encoding.TextMarshaler{pfmt.String("message")}}
+
+	for i := 0; i < 10; i++ {
+		_, err := l.Write([]byte("Hello, World!"))
+		if err != nil {
+			t.Fatalf("unwant write error: %s", err)
+		}
+	}
+
+	err := f.Close()
+	if err != nil {
+		t.Fatalf("unwant close error: %s", err)
+	}
+
+	matches, err := filepath.Glob(path + ".*")
+	if err != nil {
+		t.Fatalf("unwant glob error: %s", err)
+	}
+	if len(matches) != 2 {
+		t.Errorf("want 2 backups, got: %q", matches)
+	}
+
+	for _, m := range append(matches, path) {
+		p, err := os.ReadFile(m)
+		if err != nil {
+			t.Fatalf("unwant read error: %s", err)
+		}
+		if len(p) > 64 {
+			t.Errorf("want file size not greater than 64, got: %d", len(p))
+		}
+		for _, line := range strings.Split(strings.TrimSuffix(string(p), "\n"), "\n") {
+			if line != `{"message":"Hello, World!"}` {
+				t.Errorf("unwant record: %q", line)
+			}
+		}
+	}
+}
+
+func TestRotatingFileInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plog.log")
+
+	f := &plog.RotatingFile{Path: path, Interval: time.Millisecond, Compress: true, Perm: 0o600}
+
+	for i := 0; i < 2; i++ {
+		_, err := f.Write([]byte(`{"message":"Hello, World!"}` + "\n"))
+		if err != nil {
+			t.Fatalf("unwant write error: %s", err)
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	err := f.Close()
+	if err != nil {
+		t.Fatalf("unwant close error: %s", err)
+	}
+
+	matches, err := filepath.Glob(path + ".*.gz")
+	if err != nil {
+		t.Fatalf("unwant glob error: %s", err)
+	}
+	if len(matches) != 1 {
+		t.Fatalf("want 1 compressed backup, got: %q", matches)
+	}
+
+	gz, err := os.Open(matches[0])
+	if err != nil {
+		t.Fatalf("unwant open error: %s", err)
+	}
+	defer gz.Close()
+
+	fi, err := gz.Stat()
+	if err != nil {
+		t.Fatalf("unwant stat error: %s", err)
+	}
+	if fi.Mode().Perm() != 0o600 {
+		t.Errorf("want compressed backup permissions 0600, got: %o", fi.Mode().Perm())
+	}
+
+	z, err := gzip.NewReader(gz)
+	if err != nil {
+		t.Fatalf("unwant gzip error: %s", err)
+	}
+
+	s := bufio.NewScanner(z)
+	if !s.Scan() || s.Text() != `{"message":"Hello, World!"}` {
+		t.Errorf("unwant compressed record: %q", s.Text())
+	}
+}
+
+func TestRotatingFileReopen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "plog.log")
+
+	f := &plog.RotatingFile{Path: path}
+	defer f.Close()
+
+	_, err := f.Write([]byte("first\n"))
+	if err != nil {
+		t.Fatalf("unwant write error: %s", err)
+	}
+
+	// External rotation moves the file away.
+	err = os.Rename(path, filepath.Join(dir, "plog.log.1"))
+	if err != nil {
+		t.Fatalf("unwant rename error: %s", err)
+	}
+
+	err = f.Reopen()
+	if err != nil {
+		t.Fatalf("unwant reopen error: %s", err)
+	}
+
+	_, err = f.Write([]byte("second\n"))
+	if err != nil {
+		t.Fatalf("unwant write error: %s", err)
+	}
+
+	p, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unwant read error: %s", err)
+	}
+	if string(p) != "second\n" {
+		t.Errorf("want second record in the reopened file, got: %q", p)
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plog
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pfmt/pfmt"
+)
+
+// Router is a logger which fans out each message to the multiple destinations,
+// each destination has its own logger (encoder preset and output),
+// minimum severity level and filter.
+type Router struct {
+	Routes []Route // Routes is a destinations.
+
+	level string
+	stats []*routeStats
+}
+
+// Route is a destination of the router.
+type Route struct {
+	Name   string                                  // Name is a name of the destination.
+	Log    Logger                                  // Log is a logger of the destination, for example GELF preset with the output.
+	Level  string                                  // Level is a minimum severity level, for example "warning", all messages pass if empty.
+	Filter func(level string, message []byte) bool // Filter returns false to skip the message, all messages pass if nil.
+}
+
+// RouteStats is a destination statistics.
+type RouteStats struct {
+	Name     string // Name is a name of the destination.
+	Writes   uint64 // Writes is a number of the written messages.
+	Failures uint64 // Failures is a number of the failed writes.
+	Err      error  // Err is the last write error.
+}
+
+// routeStats is a destination statistics shared by the tee'd routers.
+type routeStats struct {
+	mu sync.Mutex
+	RouteStats
+}
+
+func (s *routeStats) add(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		s.Failures++
+		s.Err = err
+		return
+	}
+	s.Writes++
+}
+
+var routerMu sync.Mutex
+
+func (r *Router) shared() []*routeStats {
+	routerMu.Lock()
+	defer routerMu.Unlock()
+	if r.stats == nil {
+		r.stats = make([]*routeStats, len(r.Routes))
+		for i, rt := range r.Routes {
+			r.stats[i] = &routeStats{RouteStats: RouteStats{Name: rt.Name}}
+		}
+	}
+	return r.stats
+}
+
+// Write implements io.Writer. Writes message to the each matched destination,
+// failure of the one destination does not prevent writes to the others.
+// Returns the first error.
+func (r *Router) Write(src []byte) (int, error) {
+	stats := r.shared()
+
+	level := r.level
+	if level == "" {
+		level = "6"
+	}
+	sev, ok := severity(level)
+	if !ok {
+		sev = 6
+	}
+
+	var first error
+
+	for i, rt := range r.Routes {
+		if rt.Log == nil {
+			continue
+		}
+
+		if rt.Level != "" {
+			min, ok := severity(rt.Level)
+			if ok && sev > min {
+				continue
+			}
+		}
+
+		if rt.Filter != nil && !rt.Filter(r.level, src) {
+			continue
+		}
+
+		_, err := rt.Log.Write(src)
+		stats[i].add(err)
+		if err != nil && first == nil {
+			first = err
+		}
+	}
+
+	return len(src), first
+}
+
+// Tee returns copy of the router with additional key-values
+// added to the logger of the each destination.
+// If first key-value pair implements the Leveler interface
+// then level of the copy is set to the severity level.
+func (r *Router) Tee(kv ...pfmt.KV) Logger {
+	r0 := &Router{
+		Routes: make([]Route, len(r.Routes)),
+		level:  r.level,
+		stats:  r.shared(),
+	}
+
+	for i, rt := range r.Routes {
+		if rt.Log != nil {
+			rt.Log = rt.Log.Tee(kv...)
+		}
+		r0.Routes[i] = rt
+	}
+
+	if len(kv) > 0 {
+		if s, ok := kv[0].(Leveler); ok {
+			r0.level = s.Level()
+		}
+	}
+
+	return r0
+}
+
+// Close closes loggers of the destinations.
+func (r *Router) Close() error {
+	var first error
+	for _, rt := range r.Routes {
+		if rt.Log == nil {
+			continue
+		}
+		err := rt.Log.Close()
+		if err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// Sync flushes loggers of the destinations.
+func (r *Router) Sync() error {
+	return r.Flush(context.Background())
+}
+
+// Flush flushes loggers of the destinations, returns the first error.
+func (r *Router) Flush(ctx context.Context) error {
+	var first error
+	for _, rt := range r.Routes {
+		if rt.Log == nil {
+			continue
+		}
+		err := rt.Log.Flush(ctx)
+		if err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// Stats returns statistics of the destinations.
+func (r *Router) Stats() []RouteStats {
+	stats := r.shared()
+	s := make([]RouteStats, len(stats))
+	for i, x := range stats {
+		x.mu.Lock()
+		s[i] = x.RouteStats
+		x.mu.Unlock()
+	}
+	return s
+}
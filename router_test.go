@@ -0,0 +1,87 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plog_test
+
+import (
+	"bytes"
+	"encoding"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kinbiko/jsonassert"
+	"github.com/pfmt/pfmt"
+	"github.com/pfmt/plog"
+)
+
+type failWriter struct{}
+
+func (failWriter) Write(p []byte) (int, error) { return 0, errors.New("unavailable") }
+
+func TestRouter(t *testing.T) {
+	var file, gelf, stderr bytes.Buffer
+
+	g := plog.GELF()
+	g.Output = &gelf
+	g.KV = g.KV[:1]
+
+	r := &plog.Router{
+		Routes: []plog.Route{
+			{
+				Name: "file",
+				Log:  &plog.Log{Output: &file, Keys: [4]encoding.TextMarshaler{pfmt.String("message")}},
+			}, {
+				Name:  "graylog",
+				Log:   g,
+				Level: "warning",
+			}, {
+				Name:  "stderr",
+				Log:   &plog.Log{Output: &stderr, Keys: [4]encoding.TextMarshaler{pfmt.String("msg")}},
+				Level: "err",
+				Filter: func(level string, message []byte) bool {
+					return !bytes.Contains(message, []byte("ignore"))
+				},
+			}, {
+				Name: "broken",
+				Log:  &plog.Log{Output: failWriter{}},
+			},
+		},
+	}
+
+	for _, tt := range []struct {
+		level string
+		input string
+	}{
+		{level: "info", input: "Hello, World!"},
+		{level: "warning", input: "Hello, Warning!"},
+		{level: "err", input: "Hello, Error!"},
+		{level: "err", input: "ignore me"},
+	} {
+		l := r.Tee(plog.StringLevel("level", tt.level))
+		_, err := l.Write([]byte(tt.input))
+		if err == nil || err.Error() != "unavailable" {
+			t.Errorf("want broken destination error, got: %v", err)
+		}
+	}
+
+	if n := strings.Count(file.String(), "\n"); n != 4 {
+		t.Errorf("want 4 file records, got: %d", n)
+	}
+
+	if n := strings.Count(gelf.String(), "\n"); n != 3 {
+		t.Errorf("want 3 graylog records, got: %d", n)
+	}
+
+	ja := jsonassert.New(testprinter{t: t, link: line()})
+	ja.Assertf(stderr.String(), `{"level":"err","msg":"Hello, Error!"}`)
+
+	stats := r.Stats()
+	if stats[3].Name != "broken" || stats[3].Failures != 4 || stats[3].Err == nil {
+		t.Errorf("want 4 failures of the broken destination, got: %+v", stats[3])
+	}
+	if stats[0].Writes != 4 || stats[0].Failures != 0 {
+		t.Errorf("want 4 writes of the file destination, got: %+v", stats[0])
+	}
+}
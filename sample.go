@@ -0,0 +1,181 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plog
+
+import (
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/pfmt/pfmt"
+)
+
+// Sampler decides whether to write the message.
+type Sampler interface {
+	// Sample receives severity level, message excerpt and key-values of the logger,
+	// reports whether to write the message and returns an optional key-value
+	// to attach to the written message.
+	Sample(level string, excerpt []byte, kv []pfmt.KV) (write bool, attach pfmt.KV)
+}
+
+// Sample is a sampler which writes first N messages of the same signature
+// per tick and thereafter every Mth, and limits rate of all messages
+// by the token bucket. Signature is a severity level and a message excerpt
+// or a severity level and a values of the keys.
+// Number of the suppressed messages is attached to the next written message.
+type Sample struct {
+	First      int           // First is a number of the messages of the same signature written per tick, sampling is off if zero.
+	Thereafter int           // Thereafter is a sampling rate of the messages after the first ones, every Mth is written, none if zero.
+	Tick       time.Duration // Tick is a sampling interval, 1 second if zero.
+	Keys       []string      // Keys is a keys of the signature instead of the message excerpt.
+	Rate       float64       // Rate is a number of the messages per second of the token bucket, rate limit is off if zero.
+	Burst      int           // Burst is a size of the token bucket, 1 if zero.
+	Key        string        // Key is a key of the suppressed messages number, "sampled_dropped" if empty.
+
+	mu      sync.Mutex
+	counts  map[uint64]*sampleCount
+	tokens  float64
+	last    time.Time
+	dropped uint64
+}
+
+type sampleCount struct {
+	tick time.Time
+	n    int
+}
+
+// Sample implements Sampler interface.
+func (s *Sample) Sample(level string, excerpt []byte, kv []pfmt.KV) (bool, pfmt.KV) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.sample(now, level, excerpt, kv) || !s.allow(now) {
+		s.dropped++
+		return false, nil
+	}
+
+	if s.dropped == 0 {
+		return true, nil
+	}
+
+	key := s.Key
+	if key == "" {
+		key = "sampled_dropped"
+	}
+
+	n := s.dropped
+	s.dropped = 0
+	return true, StringUint64(key, n)
+}
+
+// Dropped returns number of the suppressed messages not yet attached to the written message.
+func (s *Sample) Dropped() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dropped
+}
+
+// sample applies "first N per tick, then every Mth" policy to the signature.
+func (s *Sample) sample(now time.Time, level string, excerpt []byte, kv []pfmt.KV) bool {
+	if s.First <= 0 {
+		return true
+	}
+
+	tick := s.Tick
+	if tick <= 0 {
+		tick = time.Second
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(level))
+	h.Write([]byte{0})
+
+	if len(s.Keys) == 0 {
+		h.Write(excerpt)
+	} else {
+		for _, x := range kv {
+			k, err := x.MarshalText()
+			if err != nil || !contains(s.Keys, string(k)) {
+				continue
+			}
+			v, err := x.MarshalJSON()
+			if err != nil {
+				continue
+			}
+			h.Write(k)
+			h.Write([]byte{0})
+			h.Write(v)
+			h.Write([]byte{0})
+		}
+	}
+
+	if s.counts == nil {
+		s.counts = make(map[uint64]*sampleCount)
+	}
+
+	sig := h.Sum64()
+
+	c, ok := s.counts[sig]
+	if !ok || now.Sub(c.tick) >= tick {
+		// Forgets expired signatures, so counters do not grow unbounded.
+		if !ok && len(s.counts) >= 4096 {
+			for k, v := range s.counts {
+				if now.Sub(v.tick) >= tick {
+					delete(s.counts, k)
+				}
+			}
+		}
+		c = &sampleCount{tick: now}
+		s.counts[sig] = c
+	}
+
+	c.n++
+
+	if c.n <= s.First {
+		return true
+	}
+
+	return s.Thereafter > 0 && (c.n-s.First)%s.Thereafter == 0
+}
+
+// allow takes a token from the token bucket.
+func (s *Sample) allow(now time.Time) bool {
+	if s.Rate <= 0 {
+		return true
+	}
+
+	burst := float64(s.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+
+	if s.last.IsZero() {
+		s.tokens = burst
+	} else {
+		s.tokens += now.Sub(s.last).Seconds() * s.Rate
+		if s.tokens > burst {
+			s.tokens = burst
+		}
+	}
+	s.last = now
+
+	if s.tokens < 1 {
+		return false
+	}
+
+	s.tokens--
+	return true
+}
+
+func contains(a []string, s string) bool {
+	for _, x := range a {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
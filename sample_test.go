@@ -0,0 +1,118 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plog_test
+
+import (
+	"bytes"
+	"encoding"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kinbiko/jsonassert"
+	"github.com/pfmt/pfmt"
+	"github.com/pfmt/plog"
+)
+
+func TestSample(t *testing.T) {
+	tests := []struct {
+		name   string
+		line   string
+		sample *plog.Sample
+		input  []string
+		want   []string
+	}{
+		{
+			name:   "first 2 then every 3rd",
+			line:   line(),
+			sample: &plog.Sample{First: 2, Thereafter: 3, Tick: time.Hour},
+			input:  []string{"a", "a", "a", "a", "a", "b", "a", "a", "a"},
+			want: []string{
+				`{"message":"a"}`,
+				`{"message":"a"}`,
+				`{"message":"a","sampled_dropped":2}`,
+				`{"message":"b"}`,
+				`{"message":"a","sampled_dropped":2}`,
+			},
+		}, {
+			name:   "token bucket",
+			line:   line(),
+			sample: &plog.Sample{Rate: 1e-9, Burst: 2, Key: "suppressed"},
+			input:  []string{"a", "b", "c", "d"},
+			want: []string{
+				`{"message":"a"}`,
+				`{"message":"b"}`,
+			},
+		}, {
+			name:   "signature by keys",
+			line:   line(),
+			sample: &plog.Sample{First: 1, Tick: time.Hour, Keys: []string{"code"}},
+			input:  []string{"a", "b"},
+			want: []string{
+				`{"message":"a","code":42}`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.line+"/"+tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+
+			l := &plog.Log{
+				Output:  &buf,
+				Keys:    [4]encoding.TextMarshaler{pfmt.String("message")},
+				Sampler: tt.sample,
+			}
+
+			var l0 plog.Logger = l
+			if len(tt.sample.Keys) != 0 {
+				l0 = l.Tee(plog.StringInt("code", 42))
+			}
+
+			for _, s := range tt.input {
+				_, err := l0.Write([]byte(s))
+				if err != nil {
+					t.Fatalf("unwant write error: %s", err)
+				}
+			}
+
+			got := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+			if len(got) != len(tt.want) {
+				t.Fatalf("want %d records, got: %q", len(tt.want), got)
+			}
+
+			ja := jsonassert.New(testprinter{t: t, link: tt.line})
+			for i := range got {
+				ja.Assertf(got[i], tt.want[i])
+			}
+		})
+	}
+}
+
+func TestSampleLevel(t *testing.T) {
+	var buf bytes.Buffer
+
+	l := &plog.Log{
+		Output:  &buf,
+		Keys:    [4]encoding.TextMarshaler{pfmt.String("message")},
+		Sampler: &plog.Sample{First: 1, Tick: time.Hour},
+	}
+
+	for _, level := range []string{"info", "err", "info", "err"} {
+		l0 := l.Tee(plog.StringLevel("level", level))
+		_, err := l0.Write([]byte("Hello, World!"))
+		if err != nil {
+			t.Fatalf("unwant write error: %s", err)
+		}
+		l0.Close()
+	}
+
+	if n := strings.Count(buf.String(), "\n"); n != 2 {
+		t.Errorf("want 2 records of the different levels, got: %q", buf.String())
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plog
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/pfmt/pfmt"
+)
+
+// Policies of the invalid UTF-8 in the message.
+const (
+	UTF8Keep    = iota // UTF8Keep passes invalid UTF-8 to the JSON encoder as is.
+	UTF8Replace        // UTF8Replace replaces invalid bytes by the U+FFFD replacement character.
+	UTF8Escape         // UTF8Escape replaces invalid bytes by the \xNN escape sequences.
+	UTF8Base64         // UTF8Base64 replaces invalid bytes by the U+FFFD and writes base64 of the message with the flag.
+)
+
+// sanitize applies invalid UTF-8 policy to the message
+// and neutralizes terminal escape and bidirectional control characters,
+// reports whether message contains invalid UTF-8.
+// Returns the same slice if message is not changed.
+func (l *Log) sanitize(src []byte) ([]byte, bool) {
+	var p []byte
+	var invalid bool
+
+	for i := 0; i < len(src); {
+		r, n := utf8.DecodeRune(src[i:])
+
+		var repl []byte
+
+		switch {
+		case r == utf8.RuneError && n == 1:
+			invalid = true
+
+			switch l.UTF8 {
+			case UTF8Replace, UTF8Base64:
+				repl = []byte(string(utf8.RuneError))
+			case UTF8Escape:
+				repl = []byte(`\x` + strconv.FormatUint(uint64(src[i])|0x100, 16)[1:])
+			}
+
+		case l.Neutralize && control(r):
+			if r < utf8.RuneSelf {
+				repl = []byte(`\x` + strconv.FormatUint(uint64(r)|0x100, 16)[1:])
+			} else {
+				repl = []byte(`\u` + strconv.FormatUint(uint64(r)|0x10000, 16)[1:])
+			}
+		}
+
+		if repl != nil && p == nil {
+			p = append(make([]byte, 0, len(src)+len(repl)), src[:i]...)
+		}
+
+		if repl != nil {
+			p = append(p, repl...)
+		} else if p != nil {
+			p = append(p, src[i:i+n]...)
+		}
+
+		i += n
+	}
+
+	if p == nil {
+		return src, invalid
+	}
+	return p, invalid
+}
+
+// control reports whether rune is a control character
+// which may change the output of the terminal or the order of the text,
+// except of the tab and the new line characters.
+func control(r rune) bool {
+	switch {
+	case r == '\t', r == '\n', r == '\r':
+		return false
+	case r < 0x20, r >= 0x7F && r <= 0x9F:
+		return true
+	case r == 0x061C, r == 0x200E, r == 0x200F:
+		return true
+	case r >= 0x202A && r <= 0x202E, r >= 0x2066 && r <= 0x2069:
+		return true
+	}
+	return false
+}
+
+// flagInvalid writes base64 of the message with invalid UTF-8 and the flag.
+func (l *Log) flagInvalid(dst map[string]json.Marshaler, src []byte) {
+	keys := l.UTF8Keys
+	if keys[0] == "" {
+		keys[0] = "message_base64"
+	}
+	if keys[1] == "" {
+		keys[1] = "invalid_utf8"
+	}
+
+	dst[keys[0]] = StringString(keys[0], base64.StdEncoding.EncodeToString(src))
+	dst[keys[1]] = StringBool(keys[1], true)
+}
+
+// bytes returns JSON marshaler of the message.
+// Sanitized or hardened message is encoded as a JSON string with all special characters escaped,
+// so the escape sequences of the policy are kept literally.
+func (l *Log) bytes(p []byte) json.Marshaler {
+	if l.UTF8 == UTF8Keep && !l.Neutralize && !l.Harden {
+		return pfmt.Bytes(p)
+	}
+	return safeBytes(p)
+}
+
+// safeBytes is a JSON string marshaler of the slice of bytes.
+type safeBytes []byte
+
+const hexDigits = "0123456789abcdef"
+
+// MarshalJSON implements json.Marshaler.
+func (s safeBytes) MarshalJSON() ([]byte, error) {
+	p := make([]byte, 0, len(s)+2)
+	p = append(p, '"')
+
+	for i := 0; i < len(s); {
+		r, n := utf8.DecodeRune(s[i:])
+
+		switch {
+		case r == '"' || r == '\\':
+			p = append(p, '\\', byte(r))
+		case r == '\n':
+			p = append(p, '\\', 'n')
+		case r == '\r':
+			p = append(p, '\\', 'r')
+		case r == '\t':
+			p = append(p, '\\', 't')
+		case r < 0x20:
+			p = append(p, '\\', 'u', '0', '0', hexDigits[r>>4], hexDigits[r&0xF])
+		case r == utf8.RuneError && n == 1:
+			p = append(p, `\ufffd`...)
+		case r == '\u2028' || r == '\u2029':
+			p = append(p, '\\', 'u', '2', '0', '2', hexDigits[r&0xF])
+		default:
+			p = append(p, s[i:i+n]...)
+		}
+
+		i += n
+	}
+
+	return append(p, '"'), nil
+}
@@ -0,0 +1,113 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plog_test
+
+import (
+	"bytes"
+	"encoding"
+	"testing"
+
+	"github.com/kinbiko/jsonassert"
+	"github.com/pfmt/pfmt"
+	"github.com/pfmt/plog"
+)
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name       string
+		line       string
+		utf8       int
+		neutralize bool
+		input      string
+		want       string
+	}{
+		{
+			name:  "replace invalid UTF-8",
+			line:  line(),
+			utf8:  plog.UTF8Replace,
+			input: "Hello,\xff\xfe World!",
+			want: `{
+				"message":"Hello,�� World!"
+			}`,
+		}, {
+			name:  "escape invalid UTF-8",
+			line:  line(),
+			utf8:  plog.UTF8Escape,
+			input: "Hello,\xff\x0a World!",
+			want: `{
+				"message":"Hello,\\xff\n World!",
+				"excerpt":"Hello,\\xff  World!"
+			}`,
+		}, {
+			name:  "base64 of the message with invalid UTF-8",
+			line:  line(),
+			utf8:  plog.UTF8Base64,
+			input: "Hello,\xff World!",
+			want: `{
+				"message":"Hello,� World!",
+				"message_base64":"SGVsbG8s/yBXb3JsZCE=",
+				"invalid_utf8":true
+			}`,
+		}, {
+			name:  "valid UTF-8 without base64",
+			line:  line(),
+			utf8:  plog.UTF8Base64,
+			input: "Привет, Мир!",
+			want: `{
+				"message":"Привет, Мир!"
+			}`,
+		}, {
+			name:       "neutralize terminal escape and bidi control characters",
+			line:       line(),
+			neutralize: true,
+			input:      "\x1b[2Jadmin‮⁦txt.exe\u0085",
+			want: `{
+				"message":"\\x1b[2Jadmin\\u202e\\u2066txt.exe\\u0085"
+			}`,
+		}, {
+			name:       "escape backslash and quote",
+			line:       line(),
+			neutralize: true,
+			input:      `C:\dir\ "quoted" \`,
+			want: `{
+				"message":"C:\\dir\\ \"quoted\" \\"
+			}`,
+		}, {
+			name:       "keep tab and new line",
+			line:       line(),
+			neutralize: true,
+			input:      "Hello,\tWorld!",
+			want: `{
+				"message":"Hello,\tWorld!",
+				"excerpt":"Hello, World!"
+			}`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.line+"/"+tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+
+			l := &plog.Log{
+				Output:     &buf,
+				Keys:       [4]encoding.TextMarshaler{pfmt.String("message"), pfmt.String("excerpt")},
+				Replace:    [][2][]byte{{[]byte("\n"), []byte(" ")}, {[]byte("\t"), []byte(" ")}},
+				UTF8:       tt.utf8,
+				Neutralize: tt.neutralize,
+			}
+
+			_, err := l.Write([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("unwant write error: %s", err)
+			}
+
+			ja := jsonassert.New(testprinter{t: t, link: tt.line})
+			ja.Assertf(buf.String(), tt.want)
+		})
+	}
+}
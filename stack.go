@@ -0,0 +1,98 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plog
+
+import (
+	"encoding/json"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Stack attaches stack trace of the current goroutine to the records
+// of the severity level at or above the threshold.
+// Frames of the plog and the runtime packages are dropped.
+type Stack struct {
+	Level string // Level is a severity level threshold, for example "err", stack trace is off if empty.
+	Depth int    // Depth is a maximum number of the frames, 32 if zero.
+	Text  bool   // Text formats stack trace as a text like the panic does instead of an array of {func,file,line} objects.
+	Key   string // Key is a key of the stack trace, "stack" if empty.
+}
+
+// attach puts stack trace into the record if severity level reaches the threshold.
+func (s *Stack) attach(dst map[string]json.Marshaler, level string) {
+	threshold, ok := severity(s.Level)
+	if !ok {
+		return
+	}
+
+	sev, ok := severity(level)
+	if !ok || sev > threshold {
+		return
+	}
+
+	key := s.Key
+	if key == "" {
+		key = "stack"
+	}
+
+	frames := s.frames()
+
+	if s.Text {
+		var b strings.Builder
+		for i, f := range frames {
+			if i != 0 {
+				b.WriteByte('\n')
+			}
+			b.WriteString(f.Function + "\n\t" + f.File + ":" + strconv.Itoa(f.Line))
+		}
+		dst[key] = StringString(key, b.String())
+		return
+	}
+
+	dst[key] = StringArray(key, ArrayMarshalerFunc(func(enc ArrayEncoder) error {
+		for _, f := range frames {
+			f := f
+			err := enc.AppendObject(ObjectMarshalerFunc(func(enc ObjectEncoder) error {
+				enc.AddString("func", f.Function)
+				enc.AddString("file", f.File)
+				enc.AddInt64("line", int64(f.Line))
+				return nil
+			}))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+// frames returns frames of the current goroutine
+// without frames of the plog and the runtime packages.
+func (s *Stack) frames() []runtime.Frame {
+	depth := s.Depth
+	if depth <= 0 {
+		depth = 32
+	}
+
+	// Reserves room for the dropped frames of the logger.
+	pcs := make([]uintptr, depth+32)
+	n := runtime.Callers(3, pcs)
+
+	var frames []runtime.Frame
+
+	it := runtime.CallersFrames(pcs[:n])
+	for len(frames) < depth {
+		f, more := it.Next()
+		if !strings.HasPrefix(f.Function, "github.com/pfmt/plog.") && !strings.HasPrefix(f.Function, "runtime.") && f.Function != "" {
+			frames = append(frames, f)
+		}
+		if !more {
+			break
+		}
+	}
+
+	return frames
+}
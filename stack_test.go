@@ -0,0 +1,173 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plog_test
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/pfmt/pfmt"
+	"github.com/pfmt/plog"
+)
+
+func TestStack(t *testing.T) {
+	tests := []struct {
+		name  string
+		line  string
+		stack plog.Stack
+		level string
+		want  bool
+	}{
+		{
+			name:  "error reaches threshold",
+			line:  line(),
+			stack: plog.Stack{Level: "err"},
+			level: "error",
+			want:  true,
+		}, {
+			name:  "critical reaches threshold",
+			line:  line(),
+			stack: plog.Stack{Level: "err"},
+			level: "2",
+			want:  true,
+		}, {
+			name:  "warning below threshold",
+			line:  line(),
+			stack: plog.Stack{Level: "err"},
+			level: "warning",
+		}, {
+			name:  "record without level",
+			line:  line(),
+			stack: plog.Stack{Level: "err"},
+		}, {
+			name:  "off without threshold",
+			line:  line(),
+			level: "emerg",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.line+"/"+tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+
+			stack := tt.stack
+
+			l := &plog.Log{
+				Output: &buf,
+				Keys:   [4]encoding.TextMarshaler{pfmt.String("message")},
+				Stack:  &stack,
+			}
+
+			var w plog.Logger = l
+			if tt.level != "" {
+				w = l.Tee(plog.StringLevel("level", tt.level))
+			}
+
+			_, err := w.Write([]byte("Hello, World!"))
+			if err != nil {
+				t.Fatalf("unwant write error: %s", err)
+			}
+
+			var rec struct {
+				Stack []struct {
+					Func string `json:"func"`
+					File string `json:"file"`
+					Line int    `json:"line"`
+				} `json:"stack"`
+			}
+			err = json.Unmarshal(buf.Bytes(), &rec)
+			if err != nil {
+				t.Fatalf("unwant unmarshal error: %s", err)
+			}
+
+			if !tt.want {
+				if rec.Stack != nil {
+					t.Errorf("%s: unwant stack trace: %+v", tt.line, rec.Stack)
+				}
+				return
+			}
+
+			if len(rec.Stack) == 0 {
+				t.Fatalf("%s: want stack trace", tt.line)
+			}
+			if f := rec.Stack[0]; !strings.HasSuffix(f.Func, "TestStack.func1") || !strings.HasSuffix(f.File, "stack_test.go") || f.Line == 0 {
+				t.Errorf("%s: want first frame of the caller, get %+v", tt.line, f)
+			}
+			for _, f := range rec.Stack {
+				if strings.HasPrefix(f.Func, "github.com/pfmt/plog.") || strings.HasPrefix(f.Func, "runtime.") {
+					t.Errorf("%s: unwant frame %+v", tt.line, f)
+				}
+			}
+		})
+	}
+}
+
+func TestStackText(t *testing.T) {
+	var buf bytes.Buffer
+
+	l := &plog.Log{
+		Output: &buf,
+		Keys:   [4]encoding.TextMarshaler{pfmt.String("message")},
+		Stack:  &plog.Stack{Level: "err", Depth: 1, Text: true, Key: "stacktrace"},
+	}
+
+	_, err := l.Tee(plog.StringLevel("level", "err")).Write([]byte("Hello, World!"))
+	if err != nil {
+		t.Fatalf("unwant write error: %s", err)
+	}
+
+	var rec struct {
+		Stacktrace string `json:"stacktrace"`
+	}
+	err = json.Unmarshal(buf.Bytes(), &rec)
+	if err != nil {
+		t.Fatalf("unwant unmarshal error: %s", err)
+	}
+
+	if !strings.HasPrefix(rec.Stacktrace, "github.com/pfmt/plog_test.TestStackText\n\t") || strings.Count(rec.Stacktrace, "\n") != 1 {
+		t.Errorf("want one frame of the test, get %q", rec.Stacktrace)
+	}
+}
+
+type dropSampler struct{}
+
+func (dropSampler) Sample(string, []byte, []pfmt.KV) (bool, pfmt.KV) { return false, nil }
+
+func TestStackSampled(t *testing.T) {
+	var buf bytes.Buffer
+
+	write := func(stack *plog.Stack) float64 {
+		l := &plog.Log{
+			Output:  &buf,
+			Keys:    [4]encoding.TextMarshaler{pfmt.String("message")},
+			Sampler: dropSampler{},
+			Stack:   stack,
+		}
+		l0 := l.Tee(plog.StringLevel("level", "err"))
+		defer l0.Close()
+
+		return testing.AllocsPerRun(100, func() {
+			_, err := l0.Write([]byte("Hello, World!"))
+			if err != nil {
+				t.Fatalf("unwant write error: %s", err)
+			}
+		})
+	}
+
+	without, with := write(nil), write(&plog.Stack{Level: "err"})
+	if with != without && !race {
+		t.Errorf("want no stack trace of the dropped records, get %v allocations instead of %v", with, without)
+	}
+
+	if buf.Len() != 0 {
+		t.Errorf("unwant dropped records: %s", buf.String())
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plog
+
+import (
+	"bytes"
+	"log"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/pfmt/pfmt"
+)
+
+// NewStdLogger returns standard library logger, for example ErrorLog
+// of the http.Server or the httputil.ReverseProxy, which writes records
+// of the severity level with the "component" key-value by the logger.
+// Known net/http messages are extracted into the structured key-values.
+func NewStdLogger(l Logger, level, component string) *log.Logger {
+	return log.New(&stdWriter{log: l, level: level, component: component}, "", 0)
+}
+
+// stdWriter is a writer of the standard library logger.
+type stdWriter struct {
+	log       Logger
+	level     string
+	component string
+}
+
+// Write writes the message of the standard library logger without the trailing new line.
+func (w *stdWriter) Write(p []byte) (int, error) {
+	msg := bytes.TrimSuffix(p, []byte("\n"))
+
+	// Severity level goes first, so the Level function of the logger selects the output.
+	kv := []pfmt.KV{levelKV(w.log, w.level)}
+	if w.component != "" {
+		kv = append(kv, StringString("component", w.component))
+	}
+	kv = append(kv, stdFields(msg)...)
+
+	l := w.log.Tee(kv...)
+	defer l.Close()
+
+	_, err := l.Write(msg)
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// stdMessage is a known net/http message.
+type stdMessage struct {
+	event  string
+	re     *regexp.Regexp
+	fields func(m [][]byte) []pfmt.KV
+}
+
+var stdMessages = []stdMessage{
+	{
+		event: "tls_handshake_error",
+		re:    regexp.MustCompile(`^http: TLS handshake error from (\S+): (.*)$`),
+		fields: func(m [][]byte) []pfmt.KV {
+			return []pfmt.KV{StringString("remote_addr", string(m[1])), StringString("error", string(m[2]))}
+		},
+	},
+	{
+		event: "superfluous_write_header",
+		re:    regexp.MustCompile(`^http: superfluous response\.WriteHeader call from (\S+) \((.+):(\d+)\)$`),
+		fields: func(m [][]byte) []pfmt.KV {
+			line, _ := strconv.Atoi(string(m[3]))
+			return []pfmt.KV{StringString("func", string(m[1])), StringString("file", string(m[2])), StringInt("line", line)}
+		},
+	},
+	{
+		event: "accept_error",
+		re:    regexp.MustCompile(`^http: Accept error: (.*); retrying in (\S+)$`),
+		fields: func(m [][]byte) []pfmt.KV {
+			kv := []pfmt.KV{StringString("error", string(m[1]))}
+			if d, err := time.ParseDuration(string(m[2])); err == nil {
+				kv = append(kv, StringDuration("retry", d))
+			}
+			return kv
+		},
+	},
+	{
+		event: "panic",
+		re:    regexp.MustCompile(`(?s)^http: panic serving (\S+): (.*?)\n(goroutine .*)$`),
+		fields: func(m [][]byte) []pfmt.KV {
+			return []pfmt.KV{StringString("remote_addr", string(m[1])), StringString("panic", string(m[2])), StringString("stack", string(m[3]))}
+		},
+	},
+	{
+		event: "proxy_error",
+		re:    regexp.MustCompile(`^http(?:util)?: proxy error: (.*)$`),
+		fields: func(m [][]byte) []pfmt.KV {
+			return []pfmt.KV{StringString("error", string(m[1]))}
+		},
+	},
+}
+
+// stdFields returns key-values of the known net/http message.
+func stdFields(msg []byte) []pfmt.KV {
+	for _, x := range stdMessages {
+		m := x.re.FindSubmatch(msg)
+		if m == nil {
+			continue
+		}
+		return append([]pfmt.KV{StringString("event", x.event)}, x.fields(m)...)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plog_test
+
+import (
+	"bytes"
+	"encoding"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kinbiko/jsonassert"
+	"github.com/pfmt/pfmt"
+	"github.com/pfmt/plog"
+)
+
+var stdLoggerTests = []struct {
+	name  string
+	line  string
+	input string
+	want  string
+}{
+	{
+		name:  "unknown message",
+		line:  line(),
+		input: "Hello, World!",
+		want: `{
+			"message":"Hello, World!",
+			"level":"error",
+			"component":"http"
+		}`,
+	},
+	{
+		name:  "TLS handshake error",
+		line:  line(),
+		input: "http: TLS handshake error from 192.0.2.1:1234: remote error: tls: bad certificate",
+		want: `{
+			"message":"http: TLS handshake error from 192.0.2.1:1234: remote error: tls: bad certificate",
+			"level":"error",
+			"component":"http",
+			"event":"tls_handshake_error",
+			"remote_addr":"192.0.2.1:1234",
+			"error":"remote error: tls: bad certificate"
+		}`,
+	},
+	{
+		name:  "superfluous WriteHeader",
+		line:  line(),
+		input: "http: superfluous response.WriteHeader call from main.handler (main.go:42)",
+		want: `{
+			"message":"http: superfluous response.WriteHeader call from main.handler (main.go:42)",
+			"level":"error",
+			"component":"http",
+			"event":"superfluous_write_header",
+			"func":"main.handler",
+			"file":"main.go",
+			"line":42
+		}`,
+	},
+	{
+		name:  "accept error",
+		line:  line(),
+		input: "http: Accept error: accept tcp [::]:80: accept4: too many open files; retrying in 5ms",
+		want: `{
+			"message":"http: Accept error: accept tcp [::]:80: accept4: too many open files; retrying in 5ms",
+			"level":"error",
+			"component":"http",
+			"event":"accept_error",
+			"error":"accept tcp [::]:80: accept4: too many open files",
+			"retry":"5ms"
+		}`,
+	},
+	{
+		name:  "panic",
+		line:  line(),
+		input: "http: panic serving 192.0.2.1:1234: boom\ngoroutine 1 [running]:\nmain.handler()",
+		want: `{
+			"message":"http: panic serving 192.0.2.1:1234: boom\ngoroutine 1 [running]:\nmain.handler()",
+			"level":"error",
+			"component":"http",
+			"event":"panic",
+			"remote_addr":"192.0.2.1:1234",
+			"panic":"boom",
+			"stack":"goroutine 1 [running]:\nmain.handler()"
+		}`,
+	},
+	{
+		name:  "reverse proxy error",
+		line:  line(),
+		input: "http: proxy error: dial tcp 127.0.0.1:8080: connect: connection refused",
+		want: `{
+			"message":"http: proxy error: dial tcp 127.0.0.1:8080: connect: connection refused",
+			"level":"error",
+			"component":"http",
+			"event":"proxy_error",
+			"error":"dial tcp 127.0.0.1:8080: connect: connection refused"
+		}`,
+	},
+}
+
+func TestStdLogger(t *testing.T) {
+	for _, tt := range stdLoggerTests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+
+			l := &plog.Log{
+				Output: &buf,
+				Keys:   [4]encoding.TextMarshaler{pfmt.String("message")},
+			}
+
+			plog.NewStdLogger(l, "error", "http").Print(tt.input)
+
+			ja := jsonassert.New(testprinter{t: t, link: tt.line})
+			ja.Assertf(buf.String(), tt.want)
+		})
+	}
+}
+
+func TestStdLoggerServer(t *testing.T) {
+	var buf syncBuffer
+
+	l := &plog.Log{
+		Output: &buf,
+		Keys:   [4]encoding.TextMarshaler{pfmt.String("message")},
+	}
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.WriteHeader(http.StatusOK)
+	}))
+	srv.Config.ErrorLog = plog.NewStdLogger(l, "warning", "http")
+	srv.Start()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("unwant get error: %s", err)
+	}
+	_ = resp.Body.Close()
+
+	srv.Close()
+
+	s := buf.String()
+	if !strings.Contains(s, `"event":"superfluous_write_header"`) || !strings.Contains(s, `"level":"warning"`) {
+		t.Errorf("want structured superfluous WriteHeader record, got: %s", s)
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plog
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pfmt/pfmt"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Trace adds trace ID, span ID and trace flags of the active OpenTelemetry span
+// of the context to the records of the logger of the context.
+type Trace struct {
+	Keys  [3]string                   // Keys: 0 = trace ID, "trace_id" if empty; 1 = span ID, "span_id" if empty; 2 = trace flags, "trace_flags" if empty.
+	Value func(traceID string) string // Value formats trace ID, for example GCP resource name, trace ID as is if nil.
+	Flags func(flags byte) pfmt.KV    // Flags returns value of the trace flags, two hex digits if nil.
+	Event string                      // Event is a severity level threshold of the span events of the records, off if empty.
+}
+
+// TraceECS returns Elastic Common Schema trace fields.
+func TraceECS() *Trace {
+	return &Trace{Keys: [3]string{"trace.id", "span.id", "trace.flags"}}
+}
+
+// TraceGELF returns GELF additional trace fields.
+func TraceGELF() *Trace {
+	return &Trace{Keys: [3]string{"_trace_id", "_span_id", "_trace_flags"}}
+}
+
+// TraceGCP returns Google Cloud Logging trace fields of the project.
+func TraceGCP(project string) *Trace {
+	return &Trace{
+		Keys:  [3]string{"logging.googleapis.com/trace", "logging.googleapis.com/spanId", "logging.googleapis.com/trace_sampled"},
+		Value: func(traceID string) string { return "projects/" + project + "/traces/" + traceID },
+		Flags: func(flags byte) pfmt.KV { return pfmt.Bool(trace.TraceFlags(flags).IsSampled()) },
+	}
+}
+
+// extract returns trace key-values of the span of the context.
+func (t *Trace) extract(ctx context.Context) []pfmt.KV {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+
+	keys := t.Keys
+	if keys[0] == "" {
+		keys[0] = "trace_id"
+	}
+	if keys[1] == "" {
+		keys[1] = "span_id"
+	}
+	if keys[2] == "" {
+		keys[2] = "trace_flags"
+	}
+
+	traceID := sc.TraceID().String()
+	if t.Value != nil {
+		traceID = t.Value(traceID)
+	}
+
+	var flags json.Marshaler = pfmt.String(sc.TraceFlags().String())
+	if t.Flags != nil {
+		flags = t.Flags(byte(sc.TraceFlags()))
+	}
+
+	return []pfmt.KV{
+		StringString(keys[0], traceID),
+		StringString(keys[1], sc.SpanID().String()),
+		kvm{K: pfmt.String(keys[2]), V: flags},
+	}
+}
+
+// event records the span event of the JSON encoded message
+// if severity level reaches the threshold.
+func (t *Trace) event(ctx context.Context, level string, msg []byte) {
+	threshold, ok := severity(t.Event)
+	if !ok {
+		return
+	}
+
+	sev, ok := severity(level)
+	if !ok || sev > threshold {
+		return
+	}
+
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+
+	var s string
+	if json.Unmarshal(msg, &s) != nil {
+		s = string(msg)
+	}
+
+	span.AddEvent("log", trace.WithAttributes(
+		attribute.String("log.severity", level),
+		attribute.String("log.message", s),
+	))
+}
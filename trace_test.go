@@ -0,0 +1,168 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plog_test
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"testing"
+
+	"github.com/kinbiko/jsonassert"
+	"github.com/pfmt/pfmt"
+	"github.com/pfmt/plog"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTrace(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	ctx, span := tp.Tracer("test").Start(context.Background(), "request")
+	sc := span.SpanContext()
+
+	var buf bytes.Buffer
+
+	l := &plog.Log{
+		Output: &buf,
+		Keys:   [4]encoding.TextMarshaler{pfmt.String("message")},
+		Trace:  &plog.Trace{Event: "error"},
+	}
+
+	ctx = plog.NewContext(ctx, l)
+
+	err := plog.InfoContext(ctx, "Hello, World!")
+	if err != nil {
+		t.Fatalf("unwant write error: %s", err)
+	}
+
+	err = plog.ErrorContext(ctx, "Goodbye, World!")
+	if err != nil {
+		t.Fatalf("unwant write error: %s", err)
+	}
+
+	span.End()
+
+	lines := bytes.Split(bytes.TrimSuffix(buf.Bytes(), []byte("\n")), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("want 2 records, got: %q", buf.String())
+	}
+
+	ja := jsonassert.New(testprinter{t: t, link: line()})
+	ja.Assertf(string(lines[0]), `{
+		"message":"Hello, World!",
+		"level":"info",
+		"trace_id":%q,
+		"span_id":%q,
+		"trace_flags":"01"
+	}`, sc.TraceID().String(), sc.SpanID().String())
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("want 1 span, got: %d", len(spans))
+	}
+
+	events := spans[0].Events
+	if len(events) != 1 {
+		t.Fatalf("want 1 span event of the error, got: %d", len(events))
+	}
+
+	for _, a := range events[0].Attributes {
+		if a.Key == "log.message" && a.Value.AsString() != "Goodbye, World!" {
+			t.Errorf("unexpected span event message: %q", a.Value.AsString())
+		}
+		if a.Key == "log.severity" && a.Value.AsString() != "error" {
+			t.Errorf("unexpected span event severity: %q", a.Value.AsString())
+		}
+	}
+}
+
+func TestTraceFormats(t *testing.T) {
+	tp := sdktrace.NewTracerProvider()
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	ctx, span := tp.Tracer("test").Start(context.Background(), "request")
+	defer span.End()
+
+	sc := span.SpanContext()
+
+	tests := []struct {
+		name  string
+		line  string
+		trace *plog.Trace
+		want  string
+	}{
+		{
+			name:  "ECS",
+			line:  line(),
+			trace: plog.TraceECS(),
+			want: `{
+				"message":"Hello, World!",
+				"trace.id":"` + sc.TraceID().String() + `",
+				"span.id":"` + sc.SpanID().String() + `",
+				"trace.flags":"01"
+			}`,
+		},
+		{
+			name:  "GCP",
+			line:  line(),
+			trace: plog.TraceGCP("my-project"),
+			want: `{
+				"message":"Hello, World!",
+				"logging.googleapis.com/trace":"projects/my-project/traces/` + sc.TraceID().String() + `",
+				"logging.googleapis.com/spanId":"` + sc.SpanID().String() + `",
+				"logging.googleapis.com/trace_sampled":true
+			}`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+
+			l := &plog.Log{
+				Output: &buf,
+				Keys:   [4]encoding.TextMarshaler{pfmt.String("message")},
+				Trace:  tt.trace,
+			}
+
+			l0 := l.Context(ctx)
+			defer l0.Close()
+
+			_, err := l0.Write([]byte("Hello, World!"))
+			if err != nil {
+				t.Fatalf("unwant write error: %s", err)
+			}
+
+			ja := jsonassert.New(testprinter{t: t, link: tt.line})
+			ja.Assertf(buf.String(), tt.want)
+		})
+	}
+}
+
+func TestTraceWithoutSpan(t *testing.T) {
+	var buf bytes.Buffer
+
+	l := &plog.Log{
+		Output: &buf,
+		Keys:   [4]encoding.TextMarshaler{pfmt.String("message")},
+	}
+
+	l0 := l.Context(context.Background())
+	defer l0.Close()
+
+	_, err := l0.Write([]byte("Hello, World!"))
+	if err != nil {
+		t.Fatalf("unwant write error: %s", err)
+	}
+
+	ja := jsonassert.New(testprinter{t: t, link: line()})
+	ja.Assertf(buf.String(), `{"message":"Hello, World!"}`)
+}
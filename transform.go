@@ -0,0 +1,79 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plog
+
+import (
+	"bytes"
+	"regexp"
+	"unicode/utf8"
+)
+
+// Targets of the transform.
+const (
+	TransformExcerpt  = 1 << iota // TransformExcerpt applies transform to the message excerpt.
+	TransformOriginal             // TransformOriginal applies transform to the original message.
+)
+
+// Transform is a regular expression replacement or a function
+// which changes the message excerpt, the original message or both.
+// Excerpt is transformed before truncation, so length of the excerpt
+// and the truncation mark are counted after transform.
+type Transform struct {
+	Regexp  *regexp.Regexp        // Regexp matches text to replace.
+	Replace []byte                // Replace is a replacement of the regular expression, may contain $1 like submatch references.
+	Func    func(p []byte) []byte // Func transforms message, applies after regular expression.
+	Target  int                   // Target is a bit set of the transform targets, the message excerpt if zero.
+}
+
+// Predefined transforms.
+var (
+	// CollapseSpace replaces runs of the white space by the single space.
+	CollapseSpace = Transform{Regexp: regexp.MustCompile(`\s+`), Replace: []byte(" ")}
+	// StripANSI removes ANSI escape codes.
+	StripANSI = Transform{Regexp: regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)|\x1b[@-Z\\-_]`)}
+	// MaskUUID replaces UUIDs by the mask.
+	MaskUUID = Transform{
+		Regexp:  regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`),
+		Replace: []byte("********-****-****-****-************"),
+	}
+)
+
+// transform applies transforms of the target to the message.
+// Returns the same slice if there is no transforms of the target.
+// Transform of the valid UTF-8 never produces invalid UTF-8:
+// broken sequences are replaced by the U+FFFD replacement character.
+func (l *Log) transform(p []byte, target int) []byte {
+	var changed bool
+	valid := utf8.Valid(p)
+
+	for _, t := range l.Transforms {
+		tgt := t.Target
+		if tgt == 0 {
+			tgt = TransformExcerpt
+		}
+		if tgt&target == 0 {
+			continue
+		}
+
+		if !changed {
+			p = append([]byte(nil), p...)
+			changed = true
+		}
+
+		if t.Regexp != nil {
+			p = t.Regexp.ReplaceAll(p, t.Replace)
+		}
+
+		if t.Func != nil {
+			p = t.Func(p)
+		}
+	}
+
+	if changed && valid && !utf8.Valid(p) {
+		p = bytes.ToValidUTF8(p, []byte("�"))
+	}
+
+	return p
+}
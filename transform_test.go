@@ -0,0 +1,101 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plog_test
+
+import (
+	"bytes"
+	"encoding"
+	"regexp"
+	"testing"
+
+	"github.com/kinbiko/jsonassert"
+	"github.com/pfmt/pfmt"
+	"github.com/pfmt/plog"
+)
+
+func TestTransform(t *testing.T) {
+	tests := []struct {
+		name       string
+		line       string
+		transforms []plog.Transform
+		trunc      int
+		input      string
+		want       string
+	}{
+		{
+			name:       "collapse white space before truncation",
+			line:       line(),
+			transforms: []plog.Transform{plog.CollapseSpace},
+			trunc:      12,
+			input:      "Hello,\n\n\t   World!",
+			want: `{
+				"message":"Hello,\n\n\t   World!",
+				"excerpt":"Hello, World…"
+			}`,
+		}, {
+			name:       "strip ANSI escape codes",
+			line:       line(),
+			transforms: []plog.Transform{plog.StripANSI},
+			input:      "\x1b[31mHello\x1b[0m, World!",
+			want: `{
+				"message":"\u001b[31mHello\u001b[0m, World!",
+				"excerpt":"Hello, World!"
+			}`,
+		}, {
+			name:       "mask UUID in the excerpt and the original message",
+			line:       line(),
+			transforms: []plog.Transform{{Regexp: plog.MaskUUID.Regexp, Replace: plog.MaskUUID.Replace, Target: plog.TransformExcerpt | plog.TransformOriginal}},
+			input:      "user 123e4567-e89b-12d3-a456-426614174000 logged in",
+			want: `{
+				"message":"user ********-****-****-****-************ logged in"
+			}`,
+		}, {
+			name:       "regexp submatch in the original message only",
+			line:       line(),
+			transforms: []plog.Transform{{Regexp: regexp.MustCompile(`id=(\d+)`), Replace: []byte("id=<$1>"), Target: plog.TransformOriginal}},
+			input:      "request id=42 done",
+			want: `{
+				"message":"request id=<42> done",
+				"excerpt":"request id=42 done"
+			}`,
+		}, {
+			name: "function which breaks UTF-8",
+			line: line(),
+			transforms: []plog.Transform{{Func: func(p []byte) []byte {
+				return p[:len(p)-1]
+			}}},
+			input: "Привет",
+			want: `{
+				"message":"Привет",
+				"excerpt":"Приве�"
+			}`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.line+"/"+tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+
+			l := &plog.Log{
+				Output:     &buf,
+				Keys:       [4]encoding.TextMarshaler{pfmt.String("message"), pfmt.String("excerpt")},
+				Trunc:      tt.trunc,
+				Marks:      [3][]byte{[]byte("…")},
+				Transforms: tt.transforms,
+			}
+
+			_, err := l.Write([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("unwant write error: %s", err)
+			}
+
+			ja := jsonassert.New(testprinter{t: t, link: tt.line})
+			ja.Assertf(buf.String(), tt.want)
+		})
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2022 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plog
+
+import (
+	"sort"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/rivo/uniseg"
+)
+
+// Units of the maximum length of an excerpt.
+const (
+	TruncBytes     = iota // TruncBytes measures excerpt in bytes.
+	TruncRunes            // TruncRunes measures excerpt in runes.
+	TruncGraphemes        // TruncGraphemes measures excerpt in grapheme clusters (UAX #29).
+	TruncWidth            // TruncWidth measures excerpt in display columns, East Asian wide characters are two columns.
+)
+
+// unit returns size in bytes and length in units of the unit at the offset,
+// n is a size of the rune at the offset.
+func (l *Log) unit(src []byte, offset, n int, bounds []int) (int, int) {
+	switch l.Unit {
+	case TruncRunes:
+		return n, 1
+
+	case TruncGraphemes, TruncWidth:
+		size := n
+		if i := sort.SearchInts(bounds, offset+1); i < len(bounds) {
+			size = bounds[i] - offset
+		}
+		if l.Unit == TruncGraphemes {
+			return size, 1
+		}
+		return size, clusterWidth(src[offset : offset+size])
+	}
+
+	return n, n
+}
+
+// graphemes returns end offsets of the grapheme clusters.
+func graphemes(src []byte) []int {
+	var bounds []int
+	g := uniseg.NewGraphemes(string(src))
+	for g.Next() {
+		_, to := g.Positions()
+		bounds = append(bounds, to)
+	}
+	return bounds
+}
+
+// clusterWidth returns number of the display columns of the grapheme cluster:
+// width of the first rune or two columns if cluster requests emoji presentation.
+func clusterWidth(cluster []byte) int {
+	r, n := utf8.DecodeRune(cluster)
+	w := runeWidth(r)
+
+	for _, r := range string(cluster[n:]) {
+		if r == '\uFE0F' {
+			return 2
+		}
+	}
+
+	return w
+}
+
+// runeWidth returns number of the display columns of the rune.
+func runeWidth(r rune) int {
+	switch {
+	case r == 0, unicode.Is(unicode.Mn, r), unicode.Is(unicode.Me, r), unicode.Is(unicode.Cf, r):
+		return 0
+	case r < 0x1100:
+		return 1
+	}
+
+	for _, rng := range wide {
+		if r >= rng[0] && r <= rng[1] {
+			return 2
+		}
+	}
+
+	return 1
+}
+
+// wide is a ranges of the East Asian wide and fullwidth characters
+// and emoji with the default emoji presentation.
+var wide = [...][2]rune{
+	{0x1100, 0x115F},   // Hangul Jamo
+	{0x231A, 0x231B},   // watch, hourglass
+	{0x2329, 0x232A},   // angle brackets
+	{0x23E9, 0x23EC},   // media controls
+	{0x23F0, 0x23F0},   // alarm clock
+	{0x23F3, 0x23F3},   // hourglass
+	{0x25FD, 0x25FE},   // small squares
+	{0x2614, 0x2615},   // umbrella, hot beverage
+	{0x2648, 0x2653},   // zodiac
+	{0x267F, 0x267F},   // wheelchair
+	{0x2693, 0x2693},   // anchor
+	{0x26A1, 0x26A1},   // high voltage
+	{0x26AA, 0x26AB},   // circles
+	{0x26BD, 0x26BE},   // balls
+	{0x26C4, 0x26C5},   // snowman, sun
+	{0x26CE, 0x26CE},   // ophiuchus
+	{0x26D4, 0x26D4},   // no entry
+	{0x26EA, 0x26EA},   // church
+	{0x26F2, 0x26F3},   // fountain, golf
+	{0x26F5, 0x26F5},   // sailboat
+	{0x26FA, 0x26FA},   // tent
+	{0x26FD, 0x26FD},   // fuel pump
+	{0x2705, 0x2705},   // check mark
+	{0x270A, 0x270B},   // fists
+	{0x2728, 0x2728},   // sparkles
+	{0x274C, 0x274C},   // cross mark
+	{0x274E, 0x274E},   // cross mark
+	{0x2753, 0x2755},   // question marks
+	{0x2757, 0x2757},   // exclamation mark
+	{0x2795, 0x2797},   // math signs
+	{0x27B0, 0x27B0},   // curly loop
+	{0x27BF, 0x27BF},   // double curly loop
+	{0x2B1B, 0x2B1C},   // large squares
+	{0x2B50, 0x2B50},   // star
+	{0x2B55, 0x2B55},   // circle
+	{0x2E80, 0x303E},   // CJK radicals, symbols and punctuation
+	{0x3041, 0x33FF},   // Hiragana, Katakana, Bopomofo, CJK compatibility
+	{0x3400, 0x4DBF},   // CJK unified ideographs extension A
+	{0x4E00, 0x9FFF},   // CJK unified ideographs
+	{0xA000, 0xA4CF},   // Yi
+	{0xA960, 0xA97F},   // Hangul Jamo extended A
+	{0xAC00, 0xD7A3},   // Hangul syllables
+	{0xF900, 0xFAFF},   // CJK compatibility ideographs
+	{0xFE10, 0xFE19},   // vertical forms
+	{0xFE30, 0xFE6F},   // CJK compatibility forms, small form variants
+	{0xFF00, 0xFF60},   // fullwidth forms
+	{0xFFE0, 0xFFE6},   // fullwidth signs
+	{0x16FE0, 0x18CFF}, // Tangut, Khitan
+	{0x1B000, 0x1B2FF}, // Kana supplement, Nushu
+	{0x1F004, 0x1F004}, // mahjong tile
+	{0x1F0CF, 0x1F0CF}, // playing card
+	{0x1F18E, 0x1F18E}, // AB button
+	{0x1F191, 0x1F19A}, // squared words
+	{0x1F200, 0x1F2FF}, // enclosed ideographic supplement
+	{0x1F300, 0x1F64F}, // miscellaneous symbols and pictographs, emoticons
+	{0x1F680, 0x1F6FF}, // transport and map symbols
+	{0x1F7E0, 0x1F7EB}, // colored circles and squares
+	{0x1F90C, 0x1F9FF}, // supplemental symbols and pictographs
+	{0x1FA70, 0x1FAFF}, // symbols and pictographs extended A
+	{0x20000, 0x3FFFD}, // CJK unified ideographs extensions B...
+}